@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
@@ -15,21 +16,45 @@ import (
 	"backend-test-golang/internal/repository"
 	"backend-test-golang/internal/services"
 	"backend-test-golang/pkg/cache"
+	"backend-test-golang/pkg/circuitbreaker"
 	"backend-test-golang/pkg/database"
 	"backend-test-golang/pkg/middlewares"
 	"backend-test-golang/pkg/skinport"
+	"backend-test-golang/pkg/skinport/salefeed"
 )
 
 func main() {
 	conf := config.Load()
 
+	if err := skinport.ValidateAppID(conf.SkinportAppID); err != nil {
+		log.Fatalf("Invalid default skinport app id: %v", err)
+	}
+
+	if err := skinport.ValidateCurrency(strings.ToUpper(conf.SkinportCurrency)); err != nil {
+		log.Fatalf("Invalid default skinport currency: %v", err)
+	}
+
 	db, err := database.Connect(conf.DBUrl)
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 	defer db.Close()
 
-	skinportClient, err := skinport.NewClient(conf.SkinportClientID, conf.SkinportClientSecret, conf.SkinportAddr)
+	skinportClient, err := skinport.NewClient(conf.SkinportClientID, conf.SkinportClientSecret, conf.SkinportAddr,
+		skinport.WithRetryPolicy(skinport.RetryPolicy{
+			MaxAttempts:       conf.SkinportRetryMaxAttempts,
+			BaseBackoff:       time.Duration(conf.SkinportRetryBaseBackoffMs) * time.Millisecond,
+			MaxBackoff:        time.Duration(conf.SkinportRetryMaxBackoffMs) * time.Millisecond,
+			Jitter:            conf.SkinportRetryJitter,
+			RetryableStatuses: conf.SkinportRetryStatuses,
+		}),
+		skinport.WithCircuitBreaker(circuitbreaker.New("skinport",
+			conf.SkinportBreakerFailures,
+			time.Duration(conf.SkinportBreakerCoolDownSeconds)*time.Second,
+			conf.SkinportBreakerHalfOpenRequests,
+		)),
+		skinport.WithMaxResponseSize(int64(conf.SkinportMaxResponseMB)<<20),
+	)
 	if err != nil {
 		log.Fatalf("Failed to create skinport client: %v", err)
 	}
@@ -38,21 +63,61 @@ func main() {
 	defer mcache.Close()
 
 	repo := repository.New(db)
-	svc := services.New(conf.CacheTTLSeconds, mcache, skinportClient, repo)
+	svc := services.New(conf, mcache, skinportClient, repo)
+	defer svc.Close()
 	handler := handlers.New(svc)
 
+	// feedDone is closed when the sale feed has stopped, right away if it is disabled.
+	feedCtx, stopFeed := context.WithCancel(context.Background())
+	defer stopFeed()
+	feedDone := make(chan struct{})
+
+	if conf.SkinportSaleFeedEnabled {
+		feed := salefeed.New(conf.SkinportSaleFeedURL, conf.SkinportAppID, strings.ToUpper(conf.SkinportCurrency))
+		svc.SetSaleFeed(feed)
+		go func() {
+			defer close(feedDone)
+			feed.Run(feedCtx)
+		}()
+	} else {
+		close(feedDone)
+	}
+
+	if conf.RefreshIntervalSeconds > 0 {
+		refresher := services.NewRefresher(svc,
+			time.Duration(conf.RefreshIntervalSeconds)*time.Second,
+			time.Duration(conf.RefreshAheadSeconds)*time.Second,
+		)
+		refresher.Start()
+		defer refresher.Stop()
+	}
+
 	mux := http.NewServeMux()
 
 	mux.Handle("/api/v1/items", middlewares.GzipEncode(http.HandlerFunc(handler.GetItems)))
+	mux.Handle("GET /api/v1/items/stream", middlewares.GzipEncode(http.HandlerFunc(handler.StreamPrices)))
+	mux.Handle("GET /api/v1/items/changes", middlewares.GzipEncode(http.HandlerFunc(handler.GetItemChanges)))
+	mux.Handle("GET /api/v1/items/{market_hash_name}", middlewares.GzipEncode(http.HandlerFunc(handler.GetItem)))
+	mux.Handle("GET /api/v1/items/{market_hash_name}/history", middlewares.GzipEncode(http.HandlerFunc(handler.GetItemHistory)))
+	mux.Handle("POST /api/v1/items/lookup", middlewares.GzipEncode(http.HandlerFunc(handler.LookupItems)))
+	mux.Handle("GET /api/v1/sales/history", middlewares.GzipEncode(http.HandlerFunc(handler.GetSalesHistory)))
+	mux.Handle("GET /api/v1/sales/out-of-stock", middlewares.GzipEncode(http.HandlerFunc(handler.GetOutOfStock)))
 
 	mux.HandleFunc("/api/v1/withdraw", handler.Withdraw)
+	mux.HandleFunc("POST /api/v1/deposit", handler.Deposit)
+	mux.HandleFunc("POST /api/v1/purchases", handler.Purchase)
+	mux.HandleFunc("POST /api/v1/transactions/{id}/refund", handler.Refund)
+	mux.HandleFunc("POST /api/v1/transfers", handler.Transfer)
 	mux.HandleFunc("/api/v1/user/balance", handler.GetBalance)
 	mux.HandleFunc("/api/v1/user/transactions", handler.GetTransactions)
+	mux.HandleFunc("POST /api/v1/user/alerts", handler.CreateAlert)
+	mux.HandleFunc("GET /api/v1/user/alerts", handler.GetAlerts)
+	mux.HandleFunc("GET /api/v1/user/alerts/{id}", handler.GetAlert)
+	mux.HandleFunc("PATCH /api/v1/user/alerts/{id}", handler.UpdateAlert)
+	mux.HandleFunc("DELETE /api/v1/user/alerts/{id}", handler.DeleteAlert)
+	mux.HandleFunc("GET /api/v1/user/alerts/{id}/deliveries", handler.GetAlertDeliveries)
 
-	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("OK"))
-	})
+	mux.HandleFunc("/health", handler.Health)
 
 	srv := &http.Server{
 		Addr:         conf.Addr,
@@ -61,6 +126,8 @@ func main() {
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
+	// Price streams never finish on their own, so they are closed when shutdown starts.
+	srv.RegisterOnShutdown(svc.CloseStreams)
 
 	go func() {
 		log.Printf("Server starting on %s", conf.Addr)
@@ -82,5 +149,12 @@ func main() {
 		log.Printf("Server forced to shutdown: %v", err)
 	}
 
+	stopFeed()
+	select {
+	case <-feedDone:
+	case <-ctx.Done():
+		log.Printf("Sale feed did not stop in time: %v", ctx.Err())
+	}
+
 	log.Println("Server stopped gracefully")
 }
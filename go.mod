@@ -10,3 +10,11 @@ require (
 )
 
 require github.com/shopspring/decimal v1.4.0
+
+require (
+	github.com/gorilla/websocket v1.5.3
+	github.com/klauspost/compress v1.18.0
+	github.com/vmihailenco/msgpack/v5 v5.4.1
+)
+
+require github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
@@ -4,31 +4,84 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
+
+	"backend-test-golang/pkg/skinport/salefeed"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	Addr                        string
-	DBUrl                       string
-	SkinportClientID            string
-	SkinportClientSecret        string
-	SkinportAddr                string
-	CacheTTLSeconds             int
-	CacheCleanUpIntervalSeconds int
+	Addr                            string
+	DBUrl                           string
+	SkinportClientID                string
+	SkinportClientSecret            string
+	SkinportAddr                    string
+	SkinportAppID                   int
+	SkinportCurrency                string
+	SkinportPartialPolicy           string
+	SkinportRetryMaxAttempts        int
+	SkinportRetryBaseBackoffMs      int
+	SkinportRetryMaxBackoffMs       int
+	SkinportRetryJitter             float64
+	SkinportRetryStatuses           []int
+	SkinportBreakerFailures         int
+	SkinportBreakerCoolDownSeconds  int
+	SkinportBreakerHalfOpenRequests int
+	SkinportMaxResponseMB           int
+	SkinportSaleFeedEnabled         bool
+	SkinportSaleFeedURL             string
+	CacheTTLSeconds                 int
+	CacheCleanUpIntervalSeconds     int
+	CacheMaxStaleSeconds            int
+	RefreshIntervalSeconds          int
+	RefreshAheadSeconds             int
+	SnapshotsEnabled                bool
+	SnapshotRetentionHours          int
+	AlertsEnabled                   bool
+	WebhookTimeoutSeconds           int
+	WebhookMaxAttempts              int
+	WebhookBaseBackoffMs            int
+	ChangesHistoryVersions          int
+	DepositMaxAmount                float64
 }
 
 func Load() *Config {
 	_ = godotenv.Load() // Load from .env file
 
 	conf := &Config{
-		CacheTTLSeconds:             getInt("CACHE_TTL", 300),             // by default, cache ttl is 5 minutes.
-		CacheCleanUpIntervalSeconds: getInt("CACHE_CLEANUP_INTERVAL", 60), // by default, cache clean up interval is a minute.
-		Addr:                        mustGetEnv("ADDR"),
-		DBUrl:                       mustGetEnv("DB_URL"),
-		SkinportAddr:                mustGetEnv("SKINPORT_ADDR"),
-		SkinportClientID:            os.Getenv("SKINPORT_CLIENT_ID"),
-		SkinportClientSecret:        os.Getenv("SKINPORT_CLIENT_SECRET"),
+		CacheTTLSeconds:                 getInt("CACHE_TTL", 300),                // by default, cache ttl is 5 minutes.
+		CacheCleanUpIntervalSeconds:     getInt("CACHE_CLEANUP_INTERVAL", 60),    // by default, cache clean up interval is a minute.
+		CacheMaxStaleSeconds:            getInt("CACHE_MAX_STALE", 3600),         // by default, an expired catalogue may be served for an hour when skinport fails.
+		RefreshIntervalSeconds:          getInt("REFRESH_INTERVAL", 15),          // by default, the catalogue expiry is checked every 15 seconds, 0 disables refresh.
+		RefreshAheadSeconds:             getInt("REFRESH_AHEAD", 60),             // by default, the catalogue is refreshed a minute before it expires.
+		SnapshotsEnabled:                getBool("SNAPSHOTS_ENABLED", true),      // by default, prices of every fetched catalogue are saved.
+		SnapshotRetentionHours:          getInt("SNAPSHOT_RETENTION_HOURS", 168), // by default, snapshots are kept for a week, 0 keeps them forever.
+		ChangesHistoryVersions:          getInt("CHANGES_HISTORY_VERSIONS", 100), // by default, changes of the last 100 catalogue versions are kept.
+		AlertsEnabled:                   getBool("ALERTS_ENABLED", true),         // by default, price alerts are checked on every complete catalogue.
+		WebhookTimeoutSeconds:           getInt("WEBHOOK_TIMEOUT", 5),            // by default, a webhook attempt times out after 5 seconds.
+		WebhookMaxAttempts:              getInt("WEBHOOK_MAX_ATTEMPTS", 3),       // by default, a failed webhook is retried twice.
+		WebhookBaseBackoffMs:            getInt("WEBHOOK_BASE_BACKOFF_MS", 1000), // by default, retries wait 1s, then 2s.
+		DepositMaxAmount:                getFloat("DEPOSIT_MAX_AMOUNT", 10000),   // by default, one deposit may add up to 10000, 0 removes the limit.
+		Addr:                            mustGetEnv("ADDR"),
+		DBUrl:                           mustGetEnv("DB_URL"),
+		SkinportAddr:                    mustGetEnv("SKINPORT_ADDR"),
+		SkinportClientID:                os.Getenv("SKINPORT_CLIENT_ID"),
+		SkinportClientSecret:            os.Getenv("SKINPORT_CLIENT_SECRET"),
+		SkinportAppID:                   getInt("SKINPORT_APP_ID", 730),               // by default, CS2 items.
+		SkinportCurrency:                getString("SKINPORT_CURRENCY", "EUR"),        // by default, prices are in EUR.
+		SkinportPartialPolicy:           getString("SKINPORT_PARTIAL_POLICY", "fail"), // by default, the catalogue fails if either side fails.
+		SkinportRetryMaxAttempts:        getInt("SKINPORT_RETRY_MAX_ATTEMPTS", 3),     // by default, a failed request is retried twice.
+		SkinportRetryBaseBackoffMs:      getInt("SKINPORT_RETRY_BASE_BACKOFF_MS", 500),
+		SkinportRetryMaxBackoffMs:       getInt("SKINPORT_RETRY_MAX_BACKOFF_MS", 5000),
+		SkinportRetryJitter:             getFloat("SKINPORT_RETRY_JITTER", 0.2), // by default, backoff is randomized by +/-20%.
+		SkinportRetryStatuses:           getIntList("SKINPORT_RETRY_STATUSES", []int{500, 502, 503, 504}),
+		SkinportBreakerFailures:         getInt("SKINPORT_BREAKER_FAILURES", 5),           // by default, the breaker opens after 5 failed calls in a row.
+		SkinportBreakerCoolDownSeconds:  getInt("SKINPORT_BREAKER_COOLDOWN", 30),          // by default, skinport is not called for 30 seconds after the breaker opens.
+		SkinportBreakerHalfOpenRequests: getInt("SKINPORT_BREAKER_HALF_OPEN_REQUESTS", 1), // by default, one probe call closes the breaker.
+		SkinportSaleFeedEnabled:         getBool("SKINPORT_SALE_FEED_ENABLED", false),     // by default, the live sale feed is not consumed.
+		SkinportSaleFeedURL:             getString("SKINPORT_SALE_FEED_URL", salefeed.DefaultURL),
+		SkinportMaxResponseMB:           getInt("SKINPORT_MAX_RESPONSE_MB", 128), // by default, a decompressed response may take up to 128 MB.
 	}
 
 	return conf
@@ -43,6 +96,14 @@ func mustGetEnv(key string) string {
 	return value
 }
 
+func getString(key string, defaultValue string) string {
+	value, found := os.LookupEnv(key)
+	if found && value != "" {
+		return value
+	}
+	return defaultValue
+}
+
 func getInt(key string, defaultValue int) int {
 	value, found := os.LookupEnv(key)
 	if found {
@@ -53,3 +114,43 @@ func getInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+func getBool(key string, defaultValue bool) bool {
+	value, found := os.LookupEnv(key)
+	if found {
+		valueBool, err := strconv.ParseBool(value)
+		if err == nil {
+			return valueBool
+		}
+	}
+	return defaultValue
+}
+
+func getFloat(key string, defaultValue float64) float64 {
+	value, found := os.LookupEnv(key)
+	if found {
+		valueFloat, err := strconv.ParseFloat(value, 64)
+		if err == nil {
+			return valueFloat
+		}
+	}
+	return defaultValue
+}
+
+// getIntList reads a comma separated list of integers, e.g. "500,502,503".
+func getIntList(key string, defaultValue []int) []int {
+	value, found := os.LookupEnv(key)
+	if !found || value == "" {
+		return defaultValue
+	}
+
+	var result []int
+	for _, part := range strings.Split(value, ",") {
+		valueInt, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return defaultValue
+		}
+		result = append(result, valueInt)
+	}
+	return result
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	errs "backend-test-golang/pkg/errors"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"backend-test-golang/internal/models"
+)
+
+func (h *Handler) CreateAlert(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateAlertRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond(w, http.StatusBadRequest, models.Response{Message: err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.defaultTimeout)
+	defer cancel()
+
+	alert, err := h.svc.CreateAlert(ctx, req)
+	if err != nil {
+		respondAlertsError(w, err)
+		return
+	}
+
+	respond(w, http.StatusCreated, models.Response{
+		Success: true,
+		Payload: alert,
+	})
+}
+
+func (h *Handler) GetAlerts(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.ParseInt(r.URL.Query().Get("user_id"), 10, 64)
+	if err != nil {
+		respond(w, http.StatusBadRequest, models.Response{Message: "invalid user id"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.defaultTimeout)
+	defer cancel()
+
+	alerts, err := h.svc.GetAlerts(ctx, userID)
+	if err != nil {
+		respondAlertsError(w, err)
+		return
+	}
+
+	respond(w, http.StatusOK, models.Response{
+		Success: true,
+		Payload: alerts,
+	})
+}
+
+func (h *Handler) GetAlert(w http.ResponseWriter, r *http.Request) {
+	userID, alertID, err := parseAlertIDs(r)
+	if err != nil {
+		respond(w, http.StatusBadRequest, models.Response{Message: err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.defaultTimeout)
+	defer cancel()
+
+	alert, err := h.svc.GetAlert(ctx, userID, alertID)
+	if err != nil {
+		respondAlertsError(w, err)
+		return
+	}
+
+	respond(w, http.StatusOK, models.Response{
+		Success: true,
+		Payload: alert,
+	})
+}
+
+func (h *Handler) UpdateAlert(w http.ResponseWriter, r *http.Request) {
+	alertID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		respond(w, http.StatusBadRequest, models.Response{Message: "invalid alert id"})
+		return
+	}
+
+	var req models.UpdateAlertRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond(w, http.StatusBadRequest, models.Response{Message: err.Error()})
+		return
+	}
+	req.ID = alertID
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.defaultTimeout)
+	defer cancel()
+
+	alert, err := h.svc.UpdateAlert(ctx, req)
+	if err != nil {
+		respondAlertsError(w, err)
+		return
+	}
+
+	respond(w, http.StatusOK, models.Response{
+		Success: true,
+		Payload: alert,
+	})
+}
+
+func (h *Handler) DeleteAlert(w http.ResponseWriter, r *http.Request) {
+	userID, alertID, err := parseAlertIDs(r)
+	if err != nil {
+		respond(w, http.StatusBadRequest, models.Response{Message: err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.defaultTimeout)
+	defer cancel()
+
+	if err = h.svc.DeleteAlert(ctx, userID, alertID); err != nil {
+		respondAlertsError(w, err)
+		return
+	}
+
+	respond(w, http.StatusOK, models.Response{Success: true})
+}
+
+func (h *Handler) GetAlertDeliveries(w http.ResponseWriter, r *http.Request) {
+	userID, alertID, err := parseAlertIDs(r)
+	if err != nil {
+		respond(w, http.StatusBadRequest, models.Response{Message: err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.defaultTimeout)
+	defer cancel()
+
+	deliveries, err := h.svc.GetAlertDeliveries(ctx, userID, alertID)
+	if err != nil {
+		respondAlertsError(w, err)
+		return
+	}
+
+	respond(w, http.StatusOK, models.Response{
+		Success: true,
+		Payload: deliveries,
+	})
+}
+
+// parseAlertIDs reads the alert id from the path and the owner from the user_id query param.
+func parseAlertIDs(r *http.Request) (int64, int64, error) {
+	userID, err := strconv.ParseInt(r.URL.Query().Get("user_id"), 10, 64)
+	if err != nil {
+		return 0, 0, errors.New("invalid user id")
+	}
+
+	alertID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		return 0, 0, errors.New("invalid alert id")
+	}
+
+	return userID, alertID, nil
+}
+
+func respondAlertsError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errs.ErrValidationFailed):
+		respond(w, http.StatusBadRequest, models.Response{Message: err.Error()})
+	case errors.Is(err, errs.ErrUserNotFound):
+		respond(w, http.StatusNotFound, models.Response{Message: "user not found"})
+	case errors.Is(err, errs.ErrAlertNotFound):
+		respond(w, http.StatusNotFound, models.Response{Message: "alert not found"})
+	case errors.Is(err, errs.ErrAlertLimitReached):
+		respond(w, http.StatusConflict, models.Response{Message: "alert limit reached"})
+	default:
+		respond(w, http.StatusInternalServerError, models.Response{Message: "internal server error"})
+	}
+}
@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"backend-test-golang/internal/models"
+)
+
+// csvFlushRows is how many CSV rows are buffered before they are written to the response.
+const csvFlushRows = 500
+
+var itemsCSVHeader = []string{
+	"market_hash_name",
+	"currency",
+	"availability",
+	"suggested_price",
+	"min_price_tradable",
+	"min_price_non_tradable",
+	"quantity_tradable",
+	"quantity_non_tradable",
+	"median_price_tradable",
+	"median_price_non_tradable",
+	"item_page",
+	"market_page",
+}
+
+// negotiateItemsFormat picks the items representation: the format query param wins over the Accept header,
+// JSON is used when neither asks for CSV or NDJSON.
+func negotiateItemsFormat(r *http.Request) models.ItemsFormat {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return models.ItemsFormat(strings.ToLower(format))
+	}
+
+	best, bestQuality := models.ItemsFormatJSON, 0.0
+	for _, mediaRange := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+		if q, err := strconv.ParseFloat(params["q"], 64); err == nil {
+			quality = q
+		}
+
+		var format models.ItemsFormat
+		switch mediaType {
+		case "text/csv":
+			format = models.ItemsFormatCSV
+		case "application/x-ndjson", "application/ndjson", "application/jsonl":
+			format = models.ItemsFormatNDJSON
+		case "application/json", "application/*", "*/*":
+			format = models.ItemsFormatJSON
+		default:
+			continue
+		}
+
+		if quality > bestQuality {
+			best, bestQuality = format, quality
+		}
+	}
+
+	return best
+}
+
+// respondItemsExport writes the page as CSV or NDJSON row by row, so a whole catalogue is never buffered.
+// The status is sent before the first row, a failure in the middle only cuts the response.
+func respondItemsExport(w http.ResponseWriter, page *models.ItemsPage, format models.ItemsFormat, params models.CatalogueParams) {
+	w.Header().Set("X-Total-Count", strconv.Itoa(page.Total))
+
+	var err error
+	switch format {
+	case models.ItemsFormatCSV:
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="items-%d-%s.csv"`, params.AppID, params.Currency))
+		w.WriteHeader(http.StatusOK)
+		err = writeItemsCSV(w, page.Items)
+	case models.ItemsFormatNDJSON:
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.WriteHeader(http.StatusOK)
+		err = writeItemsNDJSON(w, page.Items)
+	}
+
+	if err != nil {
+		log.Printf("[WARN] failed to write %s items export: %v\n", format, err)
+	}
+}
+
+func writeItemsCSV(w io.Writer, items []*models.ItemResponse) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(itemsCSVHeader); err != nil {
+		return err
+	}
+
+	for i, item := range items {
+		if err := cw.Write(itemCSVRow(item)); err != nil {
+			return err
+		}
+
+		if (i+1)%csvFlushRows == 0 {
+			cw.Flush()
+			if err := cw.Error(); err != nil {
+				return err
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func itemCSVRow(item *models.ItemResponse) []string {
+	var (
+		quantityTradable, quantityNonTradable int64
+		medianTradable, medianNonTradable     *float64
+	)
+	if item.Tradable != nil {
+		quantityTradable = item.Tradable.Quantity
+		medianTradable = item.Tradable.MedianPrice
+	}
+	if item.NonTradable != nil {
+		quantityNonTradable = item.NonTradable.Quantity
+		medianNonTradable = item.NonTradable.MedianPrice
+	}
+
+	return []string{
+		item.MarketHashName,
+		item.Currency,
+		string(item.Availability),
+		formatPrice(item.SuggestedPrice),
+		formatPrice(item.MinPriceTradable),
+		formatPrice(item.MinPriceNonTradable),
+		strconv.FormatInt(quantityTradable, 10),
+		strconv.FormatInt(quantityNonTradable, 10),
+		formatPrice(medianTradable),
+		formatPrice(medianNonTradable),
+		itemPage(item),
+		marketPage(item),
+	}
+}
+
+// formatPrice leaves the cell empty when there is no price.
+func formatPrice(price *float64) string {
+	if price == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*price, 'f', -1, 64)
+}
+
+func itemPage(item *models.ItemResponse) string {
+	if item.Tradable != nil {
+		return item.Tradable.ItemPage
+	}
+	if item.NonTradable != nil {
+		return item.NonTradable.ItemPage
+	}
+	return ""
+}
+
+func marketPage(item *models.ItemResponse) string {
+	if item.Tradable != nil {
+		return item.Tradable.MarketPage
+	}
+	if item.NonTradable != nil {
+		return item.NonTradable.MarketPage
+	}
+	return ""
+}
+
+func writeItemsNDJSON(w io.Writer, items []*models.ItemResponse) error {
+	enc := json.NewEncoder(w) // Encode ends every value with a newline
+	for _, item := range items {
+		if err := enc.Encode(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"backend-test-golang/internal/models"
+)
+
+func TestNegotiateItemsFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		accept string
+		want   models.ItemsFormat
+	}{
+		{name: "default", target: "/api/v1/items", want: models.ItemsFormatJSON},
+		{name: "csv accept", target: "/api/v1/items", accept: "text/csv", want: models.ItemsFormatCSV},
+		{name: "ndjson accept", target: "/api/v1/items", accept: "application/x-ndjson", want: models.ItemsFormatNDJSON},
+		{name: "highest quality wins", target: "/api/v1/items", accept: "application/json;q=0.5, text/csv;q=0.9", want: models.ItemsFormatCSV},
+		{name: "browser accept", target: "/api/v1/items", accept: "text/html,application/xhtml+xml,*/*;q=0.8", want: models.ItemsFormatJSON},
+		{name: "unsupported only", target: "/api/v1/items", accept: "application/xml", want: models.ItemsFormatJSON},
+		{name: "query wins over accept", target: "/api/v1/items?format=ndjson", accept: "text/csv", want: models.ItemsFormatNDJSON},
+		{name: "query is case insensitive", target: "/api/v1/items?format=CSV", want: models.ItemsFormatCSV},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+
+			if got := negotiateItemsFormat(r); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func testExportItems() []*models.ItemResponse {
+	price := func(p float64) *float64 { return &p }
+
+	return []*models.ItemResponse{
+		{
+			MarketHashName:   `AK-47 | Redline, "FT"`,
+			Currency:         "EUR",
+			Availability:     models.AvailabilityTradableOnly,
+			SuggestedPrice:   price(27),
+			MinPriceTradable: price(25.1),
+			Tradable:         &models.ItemStats{Quantity: 12, MedianPrice: price(26.5), ItemPage: "https://skinport.com/item/ak", MarketPage: "https://skinport.com/market/ak"},
+		},
+		{
+			MarketHashName:      "Sticker | Crown (Foil)",
+			Currency:            "EUR",
+			Availability:        models.AvailabilityNonTradableOnly,
+			MinPriceNonTradable: price(900),
+			NonTradable:         &models.ItemStats{Quantity: 1},
+		},
+	}
+}
+
+func TestWriteItemsCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeItemsCSV(&buf, testExportItems()); err != nil {
+		t.Fatalf("got unexpected error: %v", err)
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid csv: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("got %d rows, want header and 2 items", len(rows))
+	}
+
+	want := []string{`AK-47 | Redline, "FT"`, "EUR", "tradable_only", "27", "25.1", "", "12", "0", "26.5", "", "https://skinport.com/item/ak", "https://skinport.com/market/ak"}
+	for i := range want {
+		if rows[1][i] != want[i] {
+			t.Errorf("column %s: got %q, want %q", itemsCSVHeader[i], rows[1][i], want[i])
+		}
+	}
+	if rows[2][4] != "" || rows[2][5] != "900" || rows[2][7] != "1" {
+		t.Errorf("got row %q", rows[2])
+	}
+}
+
+func TestWriteItemsNDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeItemsNDJSON(&buf, testExportItems()); err != nil {
+		t.Fatalf("got unexpected error: %v", err)
+	}
+
+	scanner := bufio.NewScanner(&buf)
+	var names []string
+	for scanner.Scan() {
+		var item models.ItemResponse
+		if err := json.Unmarshal(scanner.Bytes(), &item); err != nil {
+			t.Fatalf("line %q is not a json object: %v", scanner.Text(), err)
+		}
+		names = append(names, item.MarketHashName)
+	}
+
+	if len(names) != 2 || names[1] != "Sticker | Crown (Foil)" {
+		t.Errorf("got items %q", names)
+	}
+}
@@ -5,8 +5,13 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
 
 	"backend-test-golang/internal/models"
@@ -33,19 +38,187 @@ func (h *Handler) GetItems(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	query, err := parseItemsQuery(r.URL.Query())
+	if err != nil {
+		respond(w, http.StatusBadRequest, models.Response{Message: err.Error()})
+		return
+	}
+	query.Format = negotiateItemsFormat(r)
+
 	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
 	defer cancel()
 
-	items, err := h.svc.GetItems(ctx)
+	page, snapshot, err := h.svc.SearchItems(ctx, query)
 	if err != nil {
-		var e *errs.ErrRateLimitExceed
-		if errors.As(err, &e) {
-			w.Header().Set("Retry-After", strconv.FormatUint(uint64(e.RetryAfter.Seconds()), 10))
-			respond(w, http.StatusTooManyRequests, models.Response{Message: err.Error()})
-			return
-		}
+		respondItemsError(w, err)
+		return
+	}
 
-		respond(w, http.StatusInternalServerError, models.Response{Message: "internal server error"})
+	setSnapshotHeaders(w, snapshot)
+	// Added, not set: the gzip middleware has already put Accept-Encoding there.
+	w.Header().Add("Vary", "Accept")
+
+	if setCacheValidators(w, r, snapshot, query.Format) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	if query.Format != models.ItemsFormatJSON {
+		respondItemsExport(w, page, query.Format, snapshot.Params)
+		return
+	}
+
+	respond(w, http.StatusOK, models.Response{
+		Success: true,
+		Payload: page,
+	})
+}
+
+func (h *Handler) GetItem(w http.ResponseWriter, r *http.Request) {
+	marketHashName := r.PathValue("market_hash_name")
+	if marketHashName == "" {
+		respond(w, http.StatusBadRequest, models.Response{Message: "no market_hash_name provided"})
+		return
+	}
+
+	params, err := parseCatalogueParams(r.URL.Query())
+	if err != nil {
+		respond(w, http.StatusBadRequest, models.Response{Message: err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancel()
+
+	item, snapshot, err := h.svc.GetItem(ctx, params, marketHashName)
+	if err != nil {
+		respondItemsError(w, err)
+		return
+	}
+
+	setSnapshotHeaders(w, snapshot)
+
+	respond(w, http.StatusOK, models.Response{
+		Success: true,
+		Payload: item,
+	})
+}
+
+func (h *Handler) GetItemHistory(w http.ResponseWriter, r *http.Request) {
+	query, err := parsePriceHistoryQuery(r.URL.Query())
+	if err != nil {
+		respond(w, http.StatusBadRequest, models.Response{Message: err.Error()})
+		return
+	}
+	query.MarketHashName = r.PathValue("market_hash_name")
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.defaultTimeout)
+	defer cancel()
+
+	history, err := h.svc.GetPriceHistory(ctx, query)
+	if err != nil {
+		respondItemsError(w, err)
+		return
+	}
+
+	respond(w, http.StatusOK, models.Response{
+		Success: true,
+		Payload: history,
+	})
+}
+
+func (h *Handler) GetItemChanges(w http.ResponseWriter, r *http.Request) {
+	params, err := parseCatalogueParams(r.URL.Query())
+	if err != nil {
+		respond(w, http.StatusBadRequest, models.Response{Message: err.Error()})
+		return
+	}
+
+	since, err := strconv.ParseInt(r.URL.Query().Get("since"), 10, 64)
+	if err != nil {
+		respond(w, http.StatusBadRequest, models.Response{Message: "invalid since"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancel()
+
+	changes, err := h.svc.GetItemChanges(ctx, params, since)
+	if err != nil {
+		respondItemsError(w, err)
+		return
+	}
+
+	w.Header().Set("X-Catalogue-Version", strconv.FormatInt(changes.Version, 10))
+
+	respond(w, http.StatusOK, models.Response{
+		Success: true,
+		Payload: changes,
+	})
+}
+
+func (h *Handler) LookupItems(w http.ResponseWriter, r *http.Request) {
+	var req models.ItemsLookupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond(w, http.StatusBadRequest, models.Response{Message: err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancel()
+
+	result, snapshot, err := h.svc.LookupItems(ctx, req)
+	if err != nil {
+		respondItemsError(w, err)
+		return
+	}
+
+	setSnapshotHeaders(w, snapshot)
+
+	respond(w, http.StatusOK, models.Response{
+		Success: true,
+		Payload: result,
+	})
+}
+
+func (h *Handler) GetSalesHistory(w http.ResponseWriter, r *http.Request) {
+	params, err := parseCatalogueParams(r.URL.Query())
+	if err != nil {
+		respond(w, http.StatusBadRequest, models.Response{Message: err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancel()
+
+	history, err := h.svc.GetSalesHistory(ctx, models.SalesHistoryRequest{
+		CatalogueParams: params,
+		MarketHashNames: r.URL.Query()["market_hash_name"],
+	})
+	if err != nil {
+		respondItemsError(w, err)
+		return
+	}
+
+	respond(w, http.StatusOK, models.Response{
+		Success: true,
+		Payload: history,
+	})
+}
+
+func (h *Handler) GetOutOfStock(w http.ResponseWriter, r *http.Request) {
+	params, err := parseCatalogueParams(r.URL.Query())
+	if err != nil {
+		respond(w, http.StatusBadRequest, models.Response{Message: err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancel()
+
+	items, err := h.svc.GetOutOfStock(ctx, params)
+	if err != nil {
+		respondItemsError(w, err)
 		return
 	}
 
@@ -55,25 +228,214 @@ func (h *Handler) GetItems(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// parseCatalogueParams reads the optional app_id and currency query params.
+func parseCatalogueParams(values url.Values) (models.CatalogueParams, error) {
+	params := models.CatalogueParams{
+		Currency: values.Get("currency"),
+	}
+
+	if values.Get("app_id") != "" {
+		appID, err := strconv.Atoi(values.Get("app_id"))
+		if err != nil {
+			return models.CatalogueParams{}, errors.New("invalid app_id")
+		}
+		params.AppID = appID
+	}
+
+	return params, nil
+}
+
+// parsePriceHistoryQuery reads granularity and the RFC 3339 from and to query params.
+func parsePriceHistoryQuery(values url.Values) (models.PriceHistoryQuery, error) {
+	params, err := parseCatalogueParams(values)
+	if err != nil {
+		return models.PriceHistoryQuery{}, err
+	}
+
+	query := models.PriceHistoryQuery{
+		CatalogueParams: params,
+		Granularity:     models.PriceHistoryGranularity(values.Get("granularity")),
+	}
+
+	for _, p := range []struct {
+		name string
+		dest *time.Time
+	}{{"from", &query.From}, {"to", &query.To}} {
+		if values.Get(p.name) == "" {
+			continue
+		}
+		if *p.dest, err = time.Parse(time.RFC3339, values.Get(p.name)); err != nil {
+			return models.PriceHistoryQuery{}, fmt.Errorf("invalid %s, expected RFC 3339 time", p.name)
+		}
+	}
+
+	return query, nil
+}
+
+// setSnapshotHeaders tells the client how old the served catalogue is and whether it is stale.
+func setSnapshotHeaders(w http.ResponseWriter, snapshot models.CatalogueSnapshot) {
+	age := max(time.Since(snapshot.FetchedAt), 0)
+	w.Header().Set("Age", strconv.FormatInt(int64(age.Seconds()), 10))
+
+	if snapshot.Version > 0 {
+		w.Header().Set("X-Catalogue-Version", strconv.FormatInt(snapshot.Version, 10))
+	}
+
+	if snapshot.Stale {
+		w.Header().Set("X-Cache", "STALE")
+	}
+
+	if snapshot.Partial {
+		w.Header().Set("Warning", "199 - "+strconv.Quote("partial catalogue: "+snapshot.Warning))
+	}
+}
+
+// setCacheValidators sets ETag, Last-Modified and Cache-Control of a catalogue response and tells whether
+// the copy the client has is still current. The ETag is weak: it covers the catalogue content, the query
+// and the negotiated format, not the encoding. If-None-Match takes precedence over If-Modified-Since.
+func setCacheValidators(w http.ResponseWriter, r *http.Request, snapshot models.CatalogueSnapshot, format models.ItemsFormat) bool {
+	query := r.URL.Query()
+	query.Set("format", string(format))
+	etag := catalogueETag(snapshot.ContentHash, query)
+	w.Header().Set("ETag", etag)
+
+	if !snapshot.ModifiedAt.IsZero() {
+		w.Header().Set("Last-Modified", snapshot.ModifiedAt.UTC().Format(http.TimeFormat))
+	}
+
+	// A stale catalogue has to be revalidated every time, a fresh one until the cache entry expires.
+	maxAge := 0
+	if !snapshot.Stale {
+		maxAge = int(max(time.Until(snapshot.ExpiresAt), 0).Seconds())
+	}
+	w.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(maxAge))
+
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		return etagMatches(ifNoneMatch, etag)
+	}
+
+	ifModifiedSince, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil || snapshot.ModifiedAt.IsZero() {
+		return false
+	}
+
+	return !snapshot.ModifiedAt.Truncate(time.Second).After(ifModifiedSince)
+}
+
+func catalogueETag(contentHash string, query url.Values) string {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(query.Encode())) // Encode sorts the params, so their order does not matter
+	return fmt.Sprintf(`W/"%s-%x"`, contentHash, h.Sum64())
+}
+
+// etagMatches compares If-None-Match entity tags weakly, as RFC 9110 requires for it.
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// respondItemsError maps errors of the items and sales endpoints to http responses.
+func respondItemsError(w http.ResponseWriter, err error) {
+	var (
+		e           *errs.ErrRateLimitExceed
+		circuitOpen *errs.ErrCircuitOpen
+	)
+	switch {
+	case errors.As(err, &e):
+		w.Header().Set("Retry-After", strconv.FormatUint(uint64(e.RetryAfter.Seconds()), 10))
+		respond(w, http.StatusTooManyRequests, models.Response{Message: err.Error()})
+	case errors.As(err, &circuitOpen):
+		w.Header().Set("Retry-After", strconv.FormatUint(uint64(max(circuitOpen.RetryAfter.Seconds(), 1)), 10))
+		respond(w, http.StatusServiceUnavailable, models.Response{Message: "skinport is unavailable"})
+	case errors.Is(err, errs.ErrValidationFailed):
+		respond(w, http.StatusBadRequest, models.Response{Message: err.Error()})
+	case errors.Is(err, errs.ErrItemNotFound):
+		respond(w, http.StatusNotFound, models.Response{Message: "item not found"})
+	case errors.Is(err, errs.ErrChangesExpired):
+		respond(w, http.StatusGone, models.Response{Message: err.Error()})
+	default:
+		respond(w, http.StatusInternalServerError, models.Response{Message: "internal server error"})
+	}
+}
+
+func parseItemsQuery(values url.Values) (models.ItemsQuery, error) {
+	params, err := parseCatalogueParams(values)
+	if err != nil {
+		return models.ItemsQuery{}, err
+	}
+
+	query := models.ItemsQuery{
+		CatalogueParams: params,
+		Name:            values.Get("name"),
+		NamePrefix:      values.Get("name_prefix"),
+		Availability:    models.Availability(values.Get("availability")),
+		SortBy:          models.ItemsSort(values.Get("sort")),
+	}
+
+	switch values.Get("order") {
+	case "", "asc":
+	case "desc":
+		query.Desc = true
+	default:
+		return models.ItemsQuery{}, errors.New("invalid order, expected asc or desc")
+	}
+
+	prices := map[string]**float64{
+		"min_price_tradable":     &query.MinPriceTradable,
+		"max_price_tradable":     &query.MaxPriceTradable,
+		"min_price_non_tradable": &query.MinPriceNonTradable,
+		"max_price_non_tradable": &query.MaxPriceNonTradable,
+	}
+	for key, dst := range prices {
+		if values.Get(key) == "" {
+			continue
+		}
+
+		price, err := strconv.ParseFloat(values.Get(key), 64)
+		if err != nil {
+			return models.ItemsQuery{}, fmt.Errorf("invalid %s", key)
+		}
+		*dst = &price
+	}
+
+	ints := map[string]*int{
+		"limit":  &query.Limit,
+		"offset": &query.Offset,
+	}
+	for key, dst := range ints {
+		if values.Get(key) == "" {
+			continue
+		}
+
+		n, err := strconv.Atoi(values.Get(key))
+		if err != nil {
+			return models.ItemsQuery{}, fmt.Errorf("invalid %s", key)
+		}
+		*dst = n
+	}
+
+	return query, nil
+}
+
 func (h *Handler) Withdraw(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		respond(w, http.StatusMethodNotAllowed, models.Response{Message: "method not allowed"})
 		return
 	}
 
-	idempotencyKey := r.Header.Get("X-Idempotency-Key")
-	if idempotencyKey == "" {
-		respond(w, http.StatusBadRequest, models.Response{Message: "no idempotency key provided"})
-		return
-	}
-
-	if uuid.Validate(idempotencyKey) != nil {
-		respond(w, http.StatusBadRequest, models.Response{Message: "invalid idempotency key provided"})
+	idempotencyKey, err := parseIdempotencyKey(r)
+	if err != nil {
+		respond(w, http.StatusBadRequest, models.Response{Message: err.Error()})
 		return
 	}
 
 	var req models.WithdrawRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
 		respond(w, http.StatusBadRequest, models.Response{Message: err.Error()})
 		return
 	}
@@ -85,14 +447,7 @@ func (h *Handler) Withdraw(w http.ResponseWriter, r *http.Request) {
 
 	withdrawal, err := h.svc.Withdraw(ctx, req)
 	if err != nil {
-		switch {
-		case errors.Is(err, errs.ErrUserNotFound):
-			respond(w, http.StatusNotFound, models.Response{Message: "user not found"})
-		case errors.Is(err, errs.ErrInsufficientBalance):
-			respond(w, http.StatusBadRequest, models.Response{Message: "insufficient balance"})
-		default:
-			respond(w, http.StatusInternalServerError, models.Response{Message: "internal server error"})
-		}
+		respondBalanceError(w, err)
 		return
 	}
 
@@ -102,6 +457,140 @@ func (h *Handler) Withdraw(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func (h *Handler) Deposit(w http.ResponseWriter, r *http.Request) {
+	idempotencyKey, err := parseIdempotencyKey(r)
+	if err != nil {
+		respond(w, http.StatusBadRequest, models.Response{Message: err.Error()})
+		return
+	}
+
+	var req models.DepositRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond(w, http.StatusBadRequest, models.Response{Message: err.Error()})
+		return
+	}
+
+	req.IdempotencyKey = idempotencyKey
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.defaultTimeout)
+	defer cancel()
+
+	deposit, err := h.svc.Deposit(ctx, req)
+	if err != nil {
+		respondBalanceError(w, err)
+		return
+	}
+
+	respond(w, http.StatusOK, models.Response{
+		Success: true,
+		Payload: deposit,
+	})
+}
+
+func (h *Handler) Refund(w http.ResponseWriter, r *http.Request) {
+	idempotencyKey, err := parseIdempotencyKey(r)
+	if err != nil {
+		respond(w, http.StatusBadRequest, models.Response{Message: err.Error()})
+		return
+	}
+
+	transactionID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		respond(w, http.StatusBadRequest, models.Response{Message: "invalid transaction id"})
+		return
+	}
+
+	// The body is optional, without it the whole remaining amount is refunded.
+	var req models.RefundRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		respond(w, http.StatusBadRequest, models.Response{Message: err.Error()})
+		return
+	}
+
+	req.IdempotencyKey = idempotencyKey
+	req.TransactionID = transactionID
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.defaultTimeout)
+	defer cancel()
+
+	refund, err := h.svc.Refund(ctx, req)
+	if err != nil {
+		respondBalanceError(w, err)
+		return
+	}
+
+	respond(w, http.StatusOK, models.Response{
+		Success: true,
+		Payload: refund,
+	})
+}
+
+func (h *Handler) Transfer(w http.ResponseWriter, r *http.Request) {
+	idempotencyKey, err := parseIdempotencyKey(r)
+	if err != nil {
+		respond(w, http.StatusBadRequest, models.Response{Message: err.Error()})
+		return
+	}
+
+	var req models.TransferRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond(w, http.StatusBadRequest, models.Response{Message: err.Error()})
+		return
+	}
+
+	req.IdempotencyKey = idempotencyKey
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.defaultTimeout)
+	defer cancel()
+
+	transfer, err := h.svc.Transfer(ctx, req)
+	if err != nil {
+		respondBalanceError(w, err)
+		return
+	}
+
+	respond(w, http.StatusOK, models.Response{
+		Success: true,
+		Payload: transfer,
+	})
+}
+
+// respondBalanceError maps errors of the operations that change the user balance.
+func respondBalanceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errs.ErrValidationFailed):
+		respond(w, http.StatusBadRequest, models.Response{Message: err.Error()})
+	case errors.Is(err, errs.ErrUserNotFound):
+		respond(w, http.StatusNotFound, models.Response{Message: "user not found"})
+	case errors.Is(err, errs.ErrInsufficientBalance):
+		respond(w, http.StatusBadRequest, models.Response{Message: "insufficient balance"})
+	case errors.Is(err, errs.ErrBalanceLimitExceeded):
+		respond(w, http.StatusBadRequest, models.Response{Message: "balance limit exceeded"})
+	case errors.Is(err, errs.ErrTransactionNotFound):
+		respond(w, http.StatusNotFound, models.Response{Message: "transaction not found"})
+	case errors.Is(err, errs.ErrNotRefundable), errors.Is(err, errs.ErrRefundExceedsAmount):
+		respond(w, http.StatusConflict, models.Response{Message: err.Error()})
+	case errors.Is(err, errs.ErrIdempotencyKeyUsed):
+		respond(w, http.StatusConflict, models.Response{Message: "idempotency key is already used"})
+	default:
+		respond(w, http.StatusInternalServerError, models.Response{Message: "internal server error"})
+	}
+}
+
+// parseIdempotencyKey reads the UUID every balance-changing request must carry in X-Idempotency-Key.
+func parseIdempotencyKey(r *http.Request) (string, error) {
+	idempotencyKey := r.Header.Get("X-Idempotency-Key")
+	if idempotencyKey == "" {
+		return "", errors.New("no idempotency key provided")
+	}
+
+	if uuid.Validate(idempotencyKey) != nil {
+		return "", errors.New("invalid idempotency key provided")
+	}
+
+	return idempotencyKey, nil
+}
+
 func (h *Handler) GetBalance(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
 		respond(w, http.StatusMethodNotAllowed, models.Response{Message: "method not allowed"})
@@ -171,6 +660,14 @@ func (h *Handler) GetTransactions(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// Health always answers 200 while the server is up, the body tells whether Skinport is reachable.
+func (h *Handler) Health(w http.ResponseWriter, r *http.Request) {
+	respond(w, http.StatusOK, models.Response{
+		Success: true,
+		Payload: h.svc.Health(),
+	})
+}
+
 func respond(w http.ResponseWriter, httpCode int, v any) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(httpCode)
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"backend-test-golang/internal/models"
+)
+
+func TestSetCacheValidators(t *testing.T) {
+	modifiedAt := time.Date(2024, 6, 1, 12, 0, 0, 500, time.UTC)
+	snapshot := models.CatalogueSnapshot{
+		ContentHash: "abc",
+		ModifiedAt:  modifiedAt,
+		ExpiresAt:   time.Now().Add(100 * time.Second),
+	}
+
+	check := func(t *testing.T, target string, header http.Header, snapshot models.CatalogueSnapshot) (bool, http.Header) {
+		t.Helper()
+
+		r := httptest.NewRequest(http.MethodGet, target, nil)
+		for key, values := range header {
+			r.Header[key] = values
+		}
+		w := httptest.NewRecorder()
+
+		return setCacheValidators(w, r, snapshot, models.ItemsFormatJSON), w.Header()
+	}
+
+	_, headers := check(t, "/api/v1/items?limit=10&sort=name", nil, snapshot)
+	etag := headers.Get("ETag")
+
+	if !strings.HasPrefix(etag, `W/"abc-`) {
+		t.Errorf("got ETag %q, want weak tag of the content hash", etag)
+	}
+	if got := headers.Get("Last-Modified"); got != "Sat, 01 Jun 2024 12:00:00 GMT" {
+		t.Errorf("got Last-Modified %q", got)
+	}
+	if got := headers.Get("Cache-Control"); got != "public, max-age=99" && got != "public, max-age=100" {
+		t.Errorf("got Cache-Control %q, want max-age of the remaining ttl", got)
+	}
+
+	tests := []struct {
+		name     string
+		target   string
+		header   http.Header
+		snapshot models.CatalogueSnapshot
+		want     bool
+	}{
+		{name: "no validators", target: "/api/v1/items?limit=10&sort=name", want: false},
+		{name: "matching etag", target: "/api/v1/items?limit=10&sort=name", header: http.Header{"If-None-Match": {etag}}, want: true},
+		{name: "query order does not matter", target: "/api/v1/items?sort=name&limit=10", header: http.Header{"If-None-Match": {etag}}, want: true},
+		{name: "one of several etags", target: "/api/v1/items?limit=10&sort=name", header: http.Header{"If-None-Match": {`"other", ` + strings.TrimPrefix(etag, "W/")}}, want: true},
+		{name: "wildcard", target: "/api/v1/items", header: http.Header{"If-None-Match": {"*"}}, want: true},
+		{name: "other query", target: "/api/v1/items?limit=20&sort=name", header: http.Header{"If-None-Match": {etag}}, want: false},
+		{
+			name: "changed content", target: "/api/v1/items?limit=10&sort=name", header: http.Header{"If-None-Match": {etag}},
+			snapshot: models.CatalogueSnapshot{ContentHash: "def", ModifiedAt: modifiedAt}, want: false,
+		},
+		{name: "not modified since", target: "/api/v1/items", header: http.Header{"If-Modified-Since": {"Sat, 01 Jun 2024 12:00:00 GMT"}}, want: true},
+		{name: "modified since", target: "/api/v1/items", header: http.Header{"If-Modified-Since": {"Sat, 01 Jun 2024 11:59:59 GMT"}}, want: false},
+		{
+			name: "etag takes precedence", target: "/api/v1/items",
+			header: http.Header{"If-None-Match": {`"other"`}, "If-Modified-Since": {"Sat, 01 Jun 2024 12:00:00 GMT"}}, want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := snapshot
+			if tt.snapshot.ContentHash != "" {
+				s = tt.snapshot
+			}
+
+			if got, _ := check(t, tt.target, tt.header, s); got != tt.want {
+				t.Errorf("got not modified %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("stale catalogue must be revalidated", func(t *testing.T) {
+		stale := snapshot
+		stale.Stale = true
+
+		if _, headers := check(t, "/api/v1/items", nil, stale); headers.Get("Cache-Control") != "public, max-age=0" {
+			t.Errorf("got Cache-Control %q", headers.Get("Cache-Control"))
+		}
+	})
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"backend-test-golang/internal/config"
+	"backend-test-golang/internal/services"
+	"backend-test-golang/pkg/cache"
+	"backend-test-golang/pkg/middlewares"
+	"backend-test-golang/pkg/skinport"
+)
+
+func TestHandler_GetItems_Vary(t *testing.T) {
+	price := 10.0
+	skinportServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode([]skinport.Item{{MarketHashName: "AK-47 | Redline (Field-Tested)", Currency: "EUR", MinPrice: &price}})
+	}))
+	defer skinportServer.Close()
+
+	client, err := skinport.NewClient("", "", skinportServer.URL)
+	if err != nil {
+		t.Fatalf("failed to create skinport client: %v", err)
+	}
+	mcache := cache.New(60)
+	defer mcache.Close()
+
+	svc := services.New(&config.Config{
+		CacheTTLSeconds:  300,
+		SkinportAppID:    skinport.DefaultAppID,
+		SkinportCurrency: skinport.DefaultCurrency,
+	}, mcache, client, nil)
+	handler := middlewares.GzipEncode(http.HandlerFunc(New(svc).GetItems))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/items", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+
+	vary := rec.Header().Values("Vary")
+	for _, want := range []string{"Accept-Encoding", "Accept"} {
+		if !slices.Contains(vary, want) {
+			t.Errorf("got Vary %q, want it to contain %s", vary, want)
+		}
+	}
+	if got := rec.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Errorf("got Content-Encoding %q, want gzip", got)
+	}
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	errs "backend-test-golang/pkg/errors"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"backend-test-golang/internal/models"
+)
+
+func (h *Handler) Purchase(w http.ResponseWriter, r *http.Request) {
+	idempotencyKey, err := parseIdempotencyKey(r)
+	if err != nil {
+		respond(w, http.StatusBadRequest, models.Response{Message: err.Error()})
+		return
+	}
+
+	var req models.PurchaseRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respond(w, http.StatusBadRequest, models.Response{Message: err.Error()})
+		return
+	}
+
+	req.IdempotencyKey = idempotencyKey
+
+	// Pricing may have to load the catalogue from Skinport, so the items timeout applies.
+	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancel()
+
+	order, err := h.svc.Purchase(ctx, req)
+	if err != nil {
+		respondPurchaseError(w, err)
+		return
+	}
+
+	respond(w, http.StatusCreated, models.Response{
+		Success: true,
+		Payload: order,
+	})
+}
+
+func respondPurchaseError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errs.ErrItemUnavailable), errors.Is(err, errs.ErrPriceAboveLimit):
+		respond(w, http.StatusConflict, models.Response{Message: err.Error()})
+	case errors.Is(err, errs.ErrPriceNotCurrent):
+		respond(w, http.StatusServiceUnavailable, models.Response{Message: "current price is not available, try again later"})
+	case errors.Is(err, errs.ErrUserNotFound),
+		errors.Is(err, errs.ErrInsufficientBalance),
+		errors.Is(err, errs.ErrIdempotencyKeyUsed):
+		respondBalanceError(w, err)
+	default:
+		respondItemsError(w, err)
+	}
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"backend-test-golang/internal/models"
+	"backend-test-golang/internal/services"
+)
+
+// streamHeartbeat keeps idle streams alive through proxies and notices disconnected clients.
+const streamHeartbeat = 15 * time.Second
+
+// StreamPrices pushes Server-Sent Events: a refresh event on every complete catalogue refresh,
+// a prices event with the price changes matching the filter and, in between, listing events with cheaper listings
+// from the live sale feed. The event id is the catalogue version, usable as since of /api/v1/items/changes after a reconnect.
+func (h *Handler) StreamPrices(w http.ResponseWriter, r *http.Request) {
+	filter, err := parsePriceStreamFilter(r)
+	if err != nil {
+		respond(w, http.StatusBadRequest, models.Response{Message: err.Error()})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+	sub, first, err := h.svc.SubscribePrices(ctx, filter)
+	cancel()
+	if err != nil {
+		if errors.Is(err, services.ErrStreamsClosed) {
+			respond(w, http.StatusServiceUnavailable, models.Response{Message: "server is shutting down"})
+			return
+		}
+		respondItemsError(w, err)
+		return
+	}
+	defer sub.Close()
+
+	rc := http.NewResponseController(w)
+	// Streams outlive the server write timeout.
+	if err = rc.SetWriteDeadline(time.Time{}); err != nil {
+		log.Printf("[WARN] StreamPrices: failed to clear write deadline: %v\n", err)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if err = writeEvent(w, rc, first); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+			if err = writeEvent(w, rc, event); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err = io.WriteString(w, ": ping\n\n"); err != nil {
+				return
+			}
+			if err = rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, rc *http.ResponseController, event models.PriceEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	if _, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.Version, event.Type, data); err != nil {
+		return err
+	}
+
+	return rc.Flush()
+}
+
+// parsePriceStreamFilter reads repeated market_hash_name, min_price, max_price, app_id and currency query params.
+func parsePriceStreamFilter(r *http.Request) (models.PriceStreamFilter, error) {
+	values := r.URL.Query()
+
+	params, err := parseCatalogueParams(values)
+	if err != nil {
+		return models.PriceStreamFilter{}, err
+	}
+
+	filter := models.PriceStreamFilter{
+		CatalogueParams: params,
+		MarketHashNames: values["market_hash_name"],
+	}
+
+	for key, dst := range map[string]**float64{
+		"min_price": &filter.MinPrice,
+		"max_price": &filter.MaxPrice,
+	} {
+		if values.Get(key) == "" {
+			continue
+		}
+
+		price, err := strconv.ParseFloat(values.Get(key), 64)
+		if err != nil {
+			return models.PriceStreamFilter{}, fmt.Errorf("invalid %s", key)
+		}
+		*dst = &price
+	}
+
+	return filter, nil
+}
@@ -0,0 +1,167 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// AlertSide is the side of the catalogue whose lowest price an alert watches.
+type AlertSide string
+
+const (
+	AlertSideTradable    AlertSide = "tradable"
+	AlertSideNonTradable AlertSide = "non_tradable"
+)
+
+type AlertCondition string
+
+const (
+	AlertConditionBelow AlertCondition = "below"
+	AlertConditionAbove AlertCondition = "above"
+)
+
+// MaxAlertsPerUser limits how many alerts a user may have.
+const MaxAlertsPerUser = 100
+
+// PriceAlert fires once when its condition becomes true and again only after it was false in between.
+// WebhookSecret is returned only when the alert is created.
+type PriceAlert struct {
+	ID              int64           `json:"id"`
+	UserID          int64           `json:"user_id"`
+	AppID           int             `json:"app_id"`
+	Currency        string          `json:"currency"`
+	MarketHashName  string          `json:"market_hash_name"`
+	Side            AlertSide       `json:"side"`
+	Condition       AlertCondition  `json:"condition"`
+	Threshold       decimal.Decimal `json:"threshold"`
+	WebhookURL      string          `json:"webhook_url"`
+	WebhookSecret   string          `json:"webhook_secret,omitempty"`
+	Active          bool            `json:"active"`
+	Triggered       bool            `json:"triggered"`
+	LastTriggeredAt *time.Time      `json:"last_triggered_at"`
+	CreatedAt       time.Time       `json:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+}
+
+// Met tells whether price satisfies the alert condition. A missing price (no listings) never does.
+func (a *PriceAlert) Met(price *float64) bool {
+	if price == nil {
+		return false
+	}
+
+	p := decimal.NewFromFloat(*price)
+	switch a.Condition {
+	case AlertConditionBelow:
+		return p.LessThan(a.Threshold)
+	case AlertConditionAbove:
+		return p.GreaterThan(a.Threshold)
+	default:
+		return false
+	}
+}
+
+// Price returns the watched price of item, nil when the side has no listings.
+func (a *PriceAlert) Price(item *ItemResponse) *float64 {
+	if a.Side == AlertSideTradable {
+		return item.MinPriceTradable
+	}
+	return item.MinPriceNonTradable
+}
+
+type CreateAlertRequest struct {
+	CatalogueParams
+	UserID         int64           `json:"user_id"`
+	MarketHashName string          `json:"market_hash_name"`
+	Side           AlertSide       `json:"side"`
+	Condition      AlertCondition  `json:"condition"`
+	Threshold      decimal.Decimal `json:"threshold"`
+	WebhookURL     string          `json:"webhook_url"`
+}
+
+func (r CreateAlertRequest) Validate() error {
+	if r.UserID <= 0 {
+		return errors.New("invalid user id")
+	}
+
+	if r.MarketHashName == "" {
+		return errors.New("market_hash_name is required")
+	}
+
+	return validateAlertFields(&r.Side, &r.Condition, &r.Threshold, &r.WebhookURL)
+}
+
+// UpdateAlertRequest changes the given fields of an alert, nil fields are kept.
+type UpdateAlertRequest struct {
+	ID         int64            `json:"-"`
+	UserID     int64            `json:"user_id"`
+	Side       *AlertSide       `json:"side"`
+	Condition  *AlertCondition  `json:"condition"`
+	Threshold  *decimal.Decimal `json:"threshold"`
+	WebhookURL *string          `json:"webhook_url"`
+	Active     *bool            `json:"active"`
+}
+
+func (r UpdateAlertRequest) Validate() error {
+	if r.UserID <= 0 {
+		return errors.New("invalid user id")
+	}
+
+	return validateAlertFields(r.Side, r.Condition, r.Threshold, r.WebhookURL)
+}
+
+func validateAlertFields(side *AlertSide, condition *AlertCondition, threshold *decimal.Decimal, webhookURL *string) error {
+	if side != nil && *side != AlertSideTradable && *side != AlertSideNonTradable {
+		return fmt.Errorf("invalid side %q, expected tradable or non_tradable", *side)
+	}
+
+	if condition != nil && *condition != AlertConditionBelow && *condition != AlertConditionAbove {
+		return fmt.Errorf("invalid condition %q, expected below or above", *condition)
+	}
+
+	if threshold != nil && !threshold.IsPositive() {
+		return errors.New("threshold must be greater than zero")
+	}
+
+	if webhookURL != nil {
+		u, err := url.ParseRequestURI(*webhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.New("webhook_url must be an absolute http or https url")
+		}
+	}
+
+	return nil
+}
+
+// AlertEvent is the JSON body of an alert webhook.
+type AlertEvent struct {
+	EventID        string          `json:"event_id"`
+	Type           string          `json:"type"`
+	AlertID        int64           `json:"alert_id"`
+	UserID         int64           `json:"user_id"`
+	AppID          int             `json:"app_id"`
+	Currency       string          `json:"currency"`
+	MarketHashName string          `json:"market_hash_name"`
+	Side           AlertSide       `json:"side"`
+	Condition      AlertCondition  `json:"condition"`
+	Threshold      decimal.Decimal `json:"threshold"`
+	Price          float64         `json:"price"`
+	TriggeredAt    time.Time       `json:"triggered_at"`
+}
+
+const AlertEventTriggered = "price_alert.triggered"
+
+type AlertDelivery struct {
+	ID         int64     `json:"id"`
+	AlertID    int64     `json:"alert_id"`
+	EventID    string    `json:"event_id"`
+	Price      float64   `json:"price"`
+	Attempts   int       `json:"attempts"`
+	StatusCode *int      `json:"status_code"`
+	Error      *string   `json:"error"`
+	Success    bool      `json:"success"`
+	CreatedAt  time.Time `json:"created_at"`
+}
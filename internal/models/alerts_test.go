@@ -0,0 +1,88 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestCreateAlertRequest_Validate(t *testing.T) {
+	valid := CreateAlertRequest{
+		UserID:         1,
+		MarketHashName: "AK-47 | Redline (Field-Tested)",
+		Side:           AlertSideTradable,
+		Condition:      AlertConditionBelow,
+		Threshold:      decimal.NewFromInt(10),
+		WebhookURL:     "https://example.com/hooks/prices",
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(r *CreateAlertRequest)
+		wantErr bool
+	}{
+		{name: "valid", modify: func(r *CreateAlertRequest) {}},
+		{name: "non tradable above", modify: func(r *CreateAlertRequest) { r.Side, r.Condition = AlertSideNonTradable, AlertConditionAbove }},
+		{name: "no user", modify: func(r *CreateAlertRequest) { r.UserID = 0 }, wantErr: true},
+		{name: "no name", modify: func(r *CreateAlertRequest) { r.MarketHashName = "" }, wantErr: true},
+		{name: "unknown side", modify: func(r *CreateAlertRequest) { r.Side = "both" }, wantErr: true},
+		{name: "unknown condition", modify: func(r *CreateAlertRequest) { r.Condition = "equal" }, wantErr: true},
+		{name: "zero threshold", modify: func(r *CreateAlertRequest) { r.Threshold = decimal.Zero }, wantErr: true},
+		{name: "relative url", modify: func(r *CreateAlertRequest) { r.WebhookURL = "/hooks" }, wantErr: true},
+		{name: "not http url", modify: func(r *CreateAlertRequest) { r.WebhookURL = "ftp://example.com/hooks" }, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := valid
+			tt.modify(&req)
+
+			err := req.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUpdateAlertRequest_Validate(t *testing.T) {
+	side := AlertSide("both")
+	threshold := decimal.NewFromInt(-1)
+	active := false
+
+	if err := (UpdateAlertRequest{UserID: 1, Active: &active}).Validate(); err != nil {
+		t.Errorf("got unexpected error: %v", err)
+	}
+	if err := (UpdateAlertRequest{UserID: 1, Side: &side}).Validate(); err == nil {
+		t.Error("expected error for unknown side")
+	}
+	if err := (UpdateAlertRequest{UserID: 1, Threshold: &threshold}).Validate(); err == nil {
+		t.Error("expected error for negative threshold")
+	}
+}
+
+func TestPriceAlert_Met(t *testing.T) {
+	price := func(p float64) *float64 { return &p }
+
+	tests := []struct {
+		name      string
+		condition AlertCondition
+		price     *float64
+		want      bool
+	}{
+		{name: "below", condition: AlertConditionBelow, price: price(9.99), want: true},
+		{name: "equal is not below", condition: AlertConditionBelow, price: price(10), want: false},
+		{name: "above", condition: AlertConditionAbove, price: price(10.01), want: true},
+		{name: "equal is not above", condition: AlertConditionAbove, price: price(10), want: false},
+		{name: "no listings", condition: AlertConditionBelow, price: nil, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			alert := PriceAlert{Condition: tt.condition, Threshold: decimal.NewFromInt(10)}
+			if got := alert.Met(tt.price); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,18 +2,48 @@ package models
 
 import (
 	"errors"
+	"fmt"
 	"github.com/shopspring/decimal"
 	"time"
 )
 
+// TransactionType tells what changed the balance. Amount is always positive, the type gives the direction.
+type TransactionType string
+
+const (
+	TransactionTypeWithdrawal  TransactionType = "withdrawal"
+	TransactionTypePurchase    TransactionType = "purchase"
+	TransactionTypeDeposit     TransactionType = "deposit"
+	TransactionTypeRefund      TransactionType = "refund"
+	TransactionTypeTransferOut TransactionType = "transfer_out"
+	TransactionTypeTransferIn  TransactionType = "transfer_in"
+)
+
+// Credit reports whether the transaction adds to the balance.
+func (t TransactionType) Credit() bool {
+	return t == TransactionTypeDeposit || t == TransactionTypeRefund || t == TransactionTypeTransferIn
+}
+
+// Refundable reports whether a transaction of this type can be refunded.
+func (t TransactionType) Refundable() bool {
+	return t == TransactionTypeWithdrawal || t == TransactionTypePurchase
+}
+
+// MaxBalance is the largest balance users.balance NUMERIC(15, 2) can hold.
+var MaxBalance = decimal.RequireFromString("9999999999999.99")
+
+// Transaction is one change of a user balance. ReferenceTransactionID links a refund to the transaction it reverses
+// and the credit of a transfer to its debit.
 type Transaction struct {
-	ID             int64           `json:"id"`
-	IdempotencyKey string          `json:"idempotency_key"`
-	UserID         int64           `json:"user_id"`
-	BalanceBefore  decimal.Decimal `json:"balance_before"`
-	BalanceAfter   decimal.Decimal `json:"balance_after"`
-	Amount         decimal.Decimal `json:"amount"`
-	CreatedAt      time.Time       `json:"created_at"`
+	ID                     int64           `json:"id"`
+	IdempotencyKey         string          `json:"idempotency_key"`
+	UserID                 int64           `json:"user_id"`
+	Type                   TransactionType `json:"type"`
+	ReferenceTransactionID *int64          `json:"reference_transaction_id"`
+	BalanceBefore          decimal.Decimal `json:"balance_before"`
+	BalanceAfter           decimal.Decimal `json:"balance_after"`
+	Amount                 decimal.Decimal `json:"amount"`
+	CreatedAt              time.Time       `json:"created_at"`
 }
 
 type WithdrawResponse struct {
@@ -41,6 +71,99 @@ func (r WithdrawRequest) Validate() error {
 	return nil
 }
 
+type DepositRequest struct {
+	IdempotencyKey string          `json:"-"`
+	Amount         decimal.Decimal `json:"amount"`
+	UserID         int64           `json:"user_id"`
+}
+
+// Validate checks the deposit fits NUMERIC(15, 2) and, when maxAmount is positive, the per-deposit limit.
+func (r DepositRequest) Validate(maxAmount decimal.Decimal) error {
+	if !r.Amount.IsPositive() {
+		return errors.New("amount must be greater than zero")
+	}
+
+	if !r.Amount.Equal(r.Amount.Round(2)) {
+		return fmt.Errorf("amount %s has more than 2 decimal places", r.Amount)
+	}
+
+	if maxAmount.IsPositive() && r.Amount.GreaterThan(maxAmount) {
+		return fmt.Errorf("amount %s is greater than the deposit limit %s", r.Amount, maxAmount)
+	}
+
+	if r.Amount.GreaterThan(MaxBalance) {
+		return fmt.Errorf("amount %s is greater than the max balance %s", r.Amount, MaxBalance)
+	}
+
+	if r.UserID <= 0 {
+		return errors.New("invalid user id")
+	}
+
+	return nil
+}
+
+// RefundRequest returns Amount of a debit transaction to the user. A zero Amount refunds what is left of it.
+type RefundRequest struct {
+	IdempotencyKey string          `json:"-"`
+	TransactionID  int64           `json:"-"`
+	Amount         decimal.Decimal `json:"amount"`
+}
+
+func (r RefundRequest) Validate() error {
+	if r.TransactionID <= 0 {
+		return errors.New("invalid transaction id")
+	}
+
+	if r.Amount.IsNegative() {
+		return errors.New("amount must not be negative")
+	}
+
+	if !r.Amount.Equal(r.Amount.Round(2)) {
+		return fmt.Errorf("amount %s has more than 2 decimal places", r.Amount)
+	}
+
+	return nil
+}
+
+// TransferRequest moves Amount from the balance of FromUserID to the balance of ToUserID.
+type TransferRequest struct {
+	IdempotencyKey string          `json:"-"`
+	FromUserID     int64           `json:"from_user_id"`
+	ToUserID       int64           `json:"to_user_id"`
+	Amount         decimal.Decimal `json:"amount"`
+}
+
+func (r TransferRequest) Validate() error {
+	if !r.Amount.IsPositive() {
+		return errors.New("amount must be greater than zero")
+	}
+
+	if !r.Amount.Equal(r.Amount.Round(2)) {
+		return fmt.Errorf("amount %s has more than 2 decimal places", r.Amount)
+	}
+
+	if r.FromUserID <= 0 || r.ToUserID <= 0 {
+		return errors.New("invalid user id")
+	}
+
+	if r.FromUserID == r.ToUserID {
+		return errors.New("can not transfer to the same user")
+	}
+
+	return nil
+}
+
+// CreditIdempotencyKey is the idempotency key of the credit transaction of a transfer.
+func (r TransferRequest) CreditIdempotencyKey() string {
+	return r.IdempotencyKey + ":credit"
+}
+
+// Transfer is the pair of transactions of one transfer: the debit of the sender and the credit of the recipient.
+type Transfer struct {
+	Debit  *Transaction `json:"debit"`
+	Credit *Transaction `json:"credit"`
+}
+
 type Balance struct {
 	UserID  int64           `json:"user_id"`
 	Balance decimal.Decimal `json:"balance"`
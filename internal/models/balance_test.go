@@ -119,3 +119,230 @@ func TestWithdrawRequest_Validate_EdgeCases(t *testing.T) {
 		}
 	})
 }
+
+func TestDepositRequest_Validate(t *testing.T) {
+	maxAmount := decimal.NewFromInt(10000)
+
+	tests := []struct {
+		name      string
+		req       DepositRequest
+		maxAmount decimal.Decimal
+		wantErr   bool
+		errMsg    string
+	}{
+		{
+			name:      "valid amount",
+			req:       DepositRequest{Amount: decimal.RequireFromString("100.50"), UserID: 1},
+			maxAmount: maxAmount,
+		},
+		{
+			name:      "trailing zeros keep the scale",
+			req:       DepositRequest{Amount: decimal.RequireFromString("100.500"), UserID: 1},
+			maxAmount: maxAmount,
+		},
+		{
+			name:      "amount equal to the limit",
+			req:       DepositRequest{Amount: maxAmount, UserID: 1},
+			maxAmount: maxAmount,
+		},
+		{
+			name:      "zero amount should fail",
+			req:       DepositRequest{Amount: decimal.Zero, UserID: 1},
+			maxAmount: maxAmount,
+			wantErr:   true,
+			errMsg:    "amount must be greater than zero",
+		},
+		{
+			name:      "negative amount should fail",
+			req:       DepositRequest{Amount: decimal.NewFromInt(-5), UserID: 1},
+			maxAmount: maxAmount,
+			wantErr:   true,
+			errMsg:    "amount must be greater than zero",
+		},
+		{
+			name:      "sub-cent amount should fail",
+			req:       DepositRequest{Amount: decimal.RequireFromString("0.001"), UserID: 1},
+			maxAmount: maxAmount,
+			wantErr:   true,
+			errMsg:    "amount 0.001 has more than 2 decimal places",
+		},
+		{
+			name:      "amount above the limit should fail",
+			req:       DepositRequest{Amount: decimal.RequireFromString("10000.01"), UserID: 1},
+			maxAmount: maxAmount,
+			wantErr:   true,
+			errMsg:    "amount 10000.01 is greater than the deposit limit 10000",
+		},
+		{
+			name:    "no limit still fits the balance column",
+			req:     DepositRequest{Amount: decimal.RequireFromString("10000000000000"), UserID: 1},
+			wantErr: true,
+			errMsg:  "amount 10000000000000 is greater than the max balance 9999999999999.99",
+		},
+		{
+			name:      "zero user_id should fail",
+			req:       DepositRequest{Amount: decimal.NewFromInt(100), UserID: 0},
+			maxAmount: maxAmount,
+			wantErr:   true,
+			errMsg:    "invalid user id",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate(tt.maxAmount)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected validation error but got nil")
+					return
+				}
+				if tt.errMsg != "" && err.Error() != tt.errMsg {
+					t.Errorf("validation error: %v, want: %v", err.Error(), tt.errMsg)
+				}
+			} else {
+				if err != nil {
+					t.Errorf("got unexpected error = %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestRefundRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     RefundRequest
+		wantErr bool
+		errMsg  string
+	}{
+		{
+			name: "partial refund",
+			req:  RefundRequest{TransactionID: 1, Amount: decimal.RequireFromString("5.25")},
+		},
+		{
+			name: "zero amount refunds the rest",
+			req:  RefundRequest{TransactionID: 1},
+		},
+		{
+			name:    "negative amount should fail",
+			req:     RefundRequest{TransactionID: 1, Amount: decimal.NewFromInt(-1)},
+			wantErr: true,
+			errMsg:  "amount must not be negative",
+		},
+		{
+			name:    "sub-cent amount should fail",
+			req:     RefundRequest{TransactionID: 1, Amount: decimal.RequireFromString("1.001")},
+			wantErr: true,
+			errMsg:  "amount 1.001 has more than 2 decimal places",
+		},
+		{
+			name:    "zero transaction id should fail",
+			req:     RefundRequest{Amount: decimal.NewFromInt(1)},
+			wantErr: true,
+			errMsg:  "invalid transaction id",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected validation error but got nil")
+					return
+				}
+				if tt.errMsg != "" && err.Error() != tt.errMsg {
+					t.Errorf("validation error: %v, want: %v", err.Error(), tt.errMsg)
+				}
+			} else {
+				if err != nil {
+					t.Errorf("got unexpected error = %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestTransactionType(t *testing.T) {
+	tests := []struct {
+		txType         TransactionType
+		wantCredit     bool
+		wantRefundable bool
+	}{
+		{txType: TransactionTypeWithdrawal, wantRefundable: true},
+		{txType: TransactionTypePurchase, wantRefundable: true},
+		{txType: TransactionTypeDeposit, wantCredit: true},
+		{txType: TransactionTypeRefund, wantCredit: true},
+		{txType: TransactionTypeTransferOut},
+		{txType: TransactionTypeTransferIn, wantCredit: true},
+	}
+
+	for _, tt := range tests {
+		if got := tt.txType.Credit(); got != tt.wantCredit {
+			t.Errorf("%s: got credit %v, want %v", tt.txType, got, tt.wantCredit)
+		}
+		if got := tt.txType.Refundable(); got != tt.wantRefundable {
+			t.Errorf("%s: got refundable %v, want %v", tt.txType, got, tt.wantRefundable)
+		}
+	}
+}
+
+func TestTransferRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     TransferRequest
+		wantErr bool
+		errMsg  string
+	}{
+		{
+			name: "valid transfer",
+			req:  TransferRequest{FromUserID: 1, ToUserID: 2, Amount: decimal.RequireFromString("10.50")},
+		},
+		{
+			name:    "zero amount should fail",
+			req:     TransferRequest{FromUserID: 1, ToUserID: 2},
+			wantErr: true,
+			errMsg:  "amount must be greater than zero",
+		},
+		{
+			name:    "sub-cent amount should fail",
+			req:     TransferRequest{FromUserID: 1, ToUserID: 2, Amount: decimal.RequireFromString("0.005")},
+			wantErr: true,
+			errMsg:  "amount 0.005 has more than 2 decimal places",
+		},
+		{
+			name:    "no recipient should fail",
+			req:     TransferRequest{FromUserID: 1, Amount: decimal.NewFromInt(1)},
+			wantErr: true,
+			errMsg:  "invalid user id",
+		},
+		{
+			name:    "transfer to self should fail",
+			req:     TransferRequest{FromUserID: 1, ToUserID: 1, Amount: decimal.NewFromInt(1)},
+			wantErr: true,
+			errMsg:  "can not transfer to the same user",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected validation error but got nil")
+					return
+				}
+				if tt.errMsg != "" && err.Error() != tt.errMsg {
+					t.Errorf("validation error: %v, want: %v", err.Error(), tt.errMsg)
+				}
+			} else {
+				if err != nil {
+					t.Errorf("got unexpected error = %v", err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package models
+
+// ItemChangeType is what changed about an item between two catalogue versions.
+type ItemChangeType string
+
+const (
+	ItemChangeAdded        ItemChangeType = "added"
+	ItemChangeRemoved      ItemChangeType = "removed"
+	ItemChangePriceUp      ItemChangeType = "price_up"
+	ItemChangePriceDown    ItemChangeType = "price_down"
+	ItemChangeAvailability ItemChangeType = "availability"
+)
+
+const (
+	SideTradable    = "tradable"
+	SideNonTradable = "non_tradable"
+)
+
+// ItemChange is one change of an item in the catalogue version Version.
+// Price changes tell the side whose min price changed; an added item carries the whole item.
+type ItemChange struct {
+	Version         int64          `json:"version"`
+	Type            ItemChangeType `json:"type"`
+	MarketHashName  string         `json:"market_hash_name"`
+	Side            string         `json:"side,omitempty"`
+	OldPrice        *float64       `json:"old_price,omitempty"`
+	NewPrice        *float64       `json:"new_price,omitempty"`
+	OldAvailability Availability   `json:"old_availability,omitempty"`
+	NewAvailability Availability   `json:"new_availability,omitempty"`
+	Item            *ItemResponse  `json:"item,omitempty"`
+}
+
+// ItemChanges are the changes of a catalogue after version Since up to version Version.
+type ItemChanges struct {
+	AppID    int          `json:"app_id"`
+	Currency string       `json:"currency"`
+	Since    int64        `json:"since"`
+	Version  int64        `json:"version"`
+	Changes  []ItemChange `json:"changes"`
+}
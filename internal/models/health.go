@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+const (
+	HealthStatusOK       = "ok"
+	HealthStatusDegraded = "degraded"
+)
+
+type Health struct {
+	Status   string         `json:"status"`
+	Skinport SkinportHealth `json:"skinport"`
+}
+
+type SkinportHealth struct {
+	Circuit           string          `json:"circuit"`
+	RemainingRequests int             `json:"remaining_requests"`
+	SaleFeed          *SaleFeedHealth `json:"sale_feed,omitempty"`
+}
+
+// SaleFeedHealth is reported only when the live sale feed is enabled.
+type SaleFeedHealth struct {
+	Connected     bool       `json:"connected"`
+	LastEventAt   *time.Time `json:"last_event_at"`
+	DroppedEvents int64      `json:"dropped_events"`
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// PriceHistoryGranularity is the size of a price history bucket, a Postgres date_trunc field.
+type PriceHistoryGranularity string
+
+const (
+	PriceHistoryHour PriceHistoryGranularity = "hour"
+	PriceHistoryDay  PriceHistoryGranularity = "day"
+	PriceHistoryWeek PriceHistoryGranularity = "week"
+)
+
+// MaxPriceHistoryBuckets limits the range of a price history request, e.g. 41 days by hour.
+const MaxPriceHistoryBuckets = 1000
+
+// DefaultPriceHistoryRange is used when the request has no from.
+const DefaultPriceHistoryRange = 30 * 24 * time.Hour
+
+func (g PriceHistoryGranularity) Duration() time.Duration {
+	switch g {
+	case PriceHistoryHour:
+		return time.Hour
+	case PriceHistoryDay:
+		return 24 * time.Hour
+	case PriceHistoryWeek:
+		return 7 * 24 * time.Hour
+	default:
+		return 0
+	}
+}
+
+// PriceHistoryQuery asks for the price history of an item in [From, To).
+type PriceHistoryQuery struct {
+	CatalogueParams
+	MarketHashName string
+	From           time.Time
+	To             time.Time
+	Granularity    PriceHistoryGranularity
+}
+
+func (q PriceHistoryQuery) Validate() error {
+	if q.MarketHashName == "" {
+		return errors.New("market_hash_name is required")
+	}
+
+	step := q.Granularity.Duration()
+	if step == 0 {
+		return fmt.Errorf("invalid granularity %q", q.Granularity)
+	}
+
+	if !q.From.Before(q.To) {
+		return errors.New("from must be before to")
+	}
+
+	if q.To.Sub(q.From)/step > MaxPriceHistoryBuckets {
+		return fmt.Errorf("range is too long: at most %d buckets of a %s", MaxPriceHistoryBuckets, q.Granularity)
+	}
+
+	return nil
+}
+
+type PriceHistory struct {
+	MarketHashName string                  `json:"market_hash_name"`
+	AppID          int                     `json:"app_id"`
+	Currency       string                  `json:"currency"`
+	Granularity    PriceHistoryGranularity `json:"granularity"`
+	From           time.Time               `json:"from"`
+	To             time.Time               `json:"to"`
+	Points         []*PriceHistoryPoint    `json:"points"`
+}
+
+// PriceHistoryPoint aggregates the snapshots of one bucket. Buckets without snapshots are omitted.
+type PriceHistoryPoint struct {
+	Time        time.Time  `json:"time"`
+	Tradable    PriceRange `json:"tradable"`
+	NonTradable PriceRange `json:"non_tradable"`
+	Snapshots   int        `json:"snapshots"`
+}
+
+// PriceRange is the min/avg/max of the lowest listing price in a bucket, nil when there were no listings.
+type PriceRange struct {
+	Min *float64 `json:"min"`
+	Avg *float64 `json:"avg"`
+	Max *float64 `json:"max"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPriceHistoryQuery_Validate(t *testing.T) {
+	to := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	query := func(granularity PriceHistoryGranularity, from time.Time) PriceHistoryQuery {
+		return PriceHistoryQuery{MarketHashName: "AK-47 | Redline (Field-Tested)", Granularity: granularity, From: from, To: to}
+	}
+
+	tests := []struct {
+		name    string
+		query   PriceHistoryQuery
+		wantErr bool
+	}{
+		{name: "day buckets", query: query(PriceHistoryDay, to.AddDate(0, 0, -30))},
+		{name: "hour buckets at the limit", query: query(PriceHistoryHour, to.Add(-MaxPriceHistoryBuckets*time.Hour))},
+		{name: "too many hour buckets", query: query(PriceHistoryHour, to.Add(-(MaxPriceHistoryBuckets+1)*time.Hour)), wantErr: true},
+		{name: "week buckets over a year", query: query(PriceHistoryWeek, to.AddDate(-1, 0, 0))},
+		{name: "unknown granularity", query: query("minute", to.Add(-time.Hour)), wantErr: true},
+		{name: "from after to", query: query(PriceHistoryDay, to.Add(time.Hour)), wantErr: true},
+		{name: "empty range", query: query(PriceHistoryDay, to), wantErr: true},
+		{name: "missing name", query: PriceHistoryQuery{Granularity: PriceHistoryDay, From: to.Add(-time.Hour), To: to}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.query.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
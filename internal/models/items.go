@@ -1,10 +1,65 @@
 package models
 
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Availability tells on which side of the Skinport market an item is listed.
+type Availability string
+
+const (
+	AvailabilityBoth            Availability = "both"
+	AvailabilityTradableOnly    Availability = "tradable_only"
+	AvailabilityNonTradableOnly Availability = "non_tradable_only"
+)
+
+// CatalogueParams selects the Skinport catalogue by game and currency. Zero values mean the configured defaults.
+type CatalogueParams struct {
+	AppID    int    `json:"app_id"`
+	Currency string `json:"currency"`
+}
+
+// CatalogueSnapshot describes the cached catalogue a response was built from.
+// A stale snapshot is served past its expiry because Skinport could not be reached,
+// a partial one lacks one side of the catalogue and Warning tells which.
+// Version is the version of the last complete catalogue in the changes feed.
+// ContentHash and ModifiedAt change only when the catalogue content does.
+type CatalogueSnapshot struct {
+	Params      CatalogueParams
+	Version     int64
+	ContentHash string
+	ModifiedAt  time.Time
+	FetchedAt   time.Time
+	ExpiresAt   time.Time
+	Stale       bool
+	Partial     bool
+	Warning     string
+}
+
 type ItemResponse struct {
-	MarketHashName      string   `json:"market_hash_name"`
-	Currency            string   `json:"currency"`
-	MinPriceTradable    *float64 `json:"min_price_tradable"`
-	MinPriceNonTradable *float64 `json:"min_price_non_tradable"`
+	MarketHashName      string       `json:"market_hash_name"`
+	Currency            string       `json:"currency"`
+	SuggestedPrice      *float64     `json:"suggested_price"`
+	MinPriceTradable    *float64     `json:"min_price_tradable"`
+	MinPriceNonTradable *float64     `json:"min_price_non_tradable"`
+	Availability        Availability `json:"availability"`
+	Tradable            *ItemStats   `json:"tradable"`
+	NonTradable         *ItemStats   `json:"non_tradable"`
+}
+
+// ItemStats holds the Skinport listing statistics of one side (tradable or non-tradable) of an item.
+type ItemStats struct {
+	MinPrice    *float64  `json:"min_price"`
+	MaxPrice    *float64  `json:"max_price"`
+	MeanPrice   *float64  `json:"mean_price"`
+	MedianPrice *float64  `json:"median_price"`
+	Quantity    int64     `json:"quantity"`
+	ItemPage    string    `json:"item_page"`
+	MarketPage  string    `json:"market_page"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
 }
 
 type GetItemsResponse struct {
@@ -17,3 +72,128 @@ type Response struct {
 	Message string `json:"message,omitempty"`
 	Payload any    `json:"payload,omitempty"`
 }
+
+// ItemsFormat is the representation of the items catalogue. CSV and NDJSON are exports:
+// they list every matching item unless a limit is given.
+type ItemsFormat string
+
+const (
+	ItemsFormatJSON   ItemsFormat = "json"
+	ItemsFormatCSV    ItemsFormat = "csv"
+	ItemsFormatNDJSON ItemsFormat = "ndjson"
+)
+
+// ItemsSort is a field the items catalogue can be ordered by.
+type ItemsSort string
+
+const (
+	ItemsSortName             ItemsSort = "name"
+	ItemsSortPriceTradable    ItemsSort = "price_tradable"
+	ItemsSortPriceNonTradable ItemsSort = "price_non_tradable"
+)
+
+const (
+	DefaultItemsLimit = 100
+	MaxItemsLimit     = 1000
+)
+
+// ItemsQuery describes filtering, sorting and pagination of the items catalogue.
+// Zero values mean "no filter".
+type ItemsQuery struct {
+	CatalogueParams
+	Name                string
+	NamePrefix          string
+	MinPriceTradable    *float64
+	MaxPriceTradable    *float64
+	MinPriceNonTradable *float64
+	MaxPriceNonTradable *float64
+	Availability        Availability
+	SortBy              ItemsSort
+	Desc                bool
+	Limit               int
+	Offset              int
+	Format              ItemsFormat
+}
+
+func (q ItemsQuery) Validate() error {
+	switch q.Availability {
+	case "", AvailabilityBoth, AvailabilityTradableOnly, AvailabilityNonTradableOnly:
+	default:
+		return fmt.Errorf("invalid availability %q", q.Availability)
+	}
+
+	switch q.Format {
+	case "", ItemsFormatJSON, ItemsFormatCSV, ItemsFormatNDJSON:
+	default:
+		return fmt.Errorf("invalid format %q, expected json, csv or ndjson", q.Format)
+	}
+
+	switch q.SortBy {
+	case "", ItemsSortName, ItemsSortPriceTradable, ItemsSortPriceNonTradable:
+	default:
+		return fmt.Errorf("invalid sort %q", q.SortBy)
+	}
+
+	for _, price := range []*float64{q.MinPriceTradable, q.MaxPriceTradable, q.MinPriceNonTradable, q.MaxPriceNonTradable} {
+		if price != nil && *price < 0 {
+			return errors.New("price must not be negative")
+		}
+	}
+
+	if q.MinPriceTradable != nil && q.MaxPriceTradable != nil && *q.MinPriceTradable > *q.MaxPriceTradable {
+		return errors.New("min tradable price is greater than max tradable price")
+	}
+
+	if q.MinPriceNonTradable != nil && q.MaxPriceNonTradable != nil && *q.MinPriceNonTradable > *q.MaxPriceNonTradable {
+		return errors.New("min non-tradable price is greater than max non-tradable price")
+	}
+
+	if q.Limit < 0 || q.Limit > MaxItemsLimit {
+		return fmt.Errorf("limit must be between 1 and %d", MaxItemsLimit)
+	}
+
+	if q.Offset < 0 {
+		return errors.New("offset must not be negative")
+	}
+
+	return nil
+}
+
+// ItemsPage is a single page of the filtered catalogue. Total is the number of items matching the filters.
+type ItemsPage struct {
+	Items  []*ItemResponse `json:"items"`
+	Total  int             `json:"total"`
+	Limit  int             `json:"limit"`
+	Offset int             `json:"offset"`
+}
+
+const MaxLookupItems = 100
+
+type ItemsLookupRequest struct {
+	CatalogueParams
+	MarketHashNames []string `json:"market_hash_names"`
+}
+
+func (r ItemsLookupRequest) Validate() error {
+	if len(r.MarketHashNames) == 0 {
+		return errors.New("market_hash_names must not be empty")
+	}
+
+	if len(r.MarketHashNames) > MaxLookupItems {
+		return fmt.Errorf("too many market_hash_names, max %d", MaxLookupItems)
+	}
+
+	for _, name := range r.MarketHashNames {
+		if name == "" {
+			return errors.New("market_hash_name must not be empty")
+		}
+	}
+
+	return nil
+}
+
+// ItemsLookupResponse contains the found items in request order and the names missing from the catalogue.
+type ItemsLookupResponse struct {
+	Items   []*ItemResponse `json:"items"`
+	Missing []string        `json:"missing"`
+}
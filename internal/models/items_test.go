@@ -0,0 +1,30 @@
+package models
+
+import "testing"
+
+func TestItemsQuery_Validate(t *testing.T) {
+	price := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name    string
+		query   ItemsQuery
+		wantErr bool
+	}{
+		{name: "empty query", query: ItemsQuery{}},
+		{name: "unknown availability", query: ItemsQuery{Availability: "sometimes"}, wantErr: true},
+		{name: "unknown sort", query: ItemsQuery{SortBy: "popularity"}, wantErr: true},
+		{name: "negative price", query: ItemsQuery{MinPriceTradable: price(-1)}, wantErr: true},
+		{name: "inverted range", query: ItemsQuery{MinPriceNonTradable: price(10), MaxPriceNonTradable: price(5)}, wantErr: true},
+		{name: "limit too large", query: ItemsQuery{Limit: MaxItemsLimit + 1}, wantErr: true},
+		{name: "negative offset", query: ItemsQuery{Offset: -1}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.query.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
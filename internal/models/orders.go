@@ -0,0 +1,58 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Order is a catalogue item bought from the user balance. Transaction is the debit that paid for it.
+type Order struct {
+	ID             int64           `json:"id"`
+	UserID         int64           `json:"user_id"`
+	AppID          int             `json:"app_id"`
+	Currency       string          `json:"currency"`
+	MarketHashName string          `json:"market_hash_name"`
+	Tradable       bool            `json:"tradable"`
+	Price          decimal.Decimal `json:"price"`
+	MaxPrice       decimal.Decimal `json:"max_price"`
+	CreatedAt      time.Time       `json:"created_at"`
+	Transaction    *Transaction    `json:"transaction"`
+}
+
+// PurchaseRequest buys an item at its current catalogue min price of the chosen side.
+// The purchase is rejected when that price is above MaxPrice.
+type PurchaseRequest struct {
+	CatalogueParams
+	IdempotencyKey string          `json:"-"`
+	UserID         int64           `json:"user_id"`
+	MarketHashName string          `json:"market_hash_name"`
+	Tradable       *bool           `json:"tradable"`
+	MaxPrice       decimal.Decimal `json:"max_price"`
+}
+
+func (r PurchaseRequest) Validate() error {
+	if r.UserID <= 0 {
+		return errors.New("invalid user id")
+	}
+
+	if r.MarketHashName == "" {
+		return errors.New("market_hash_name must not be empty")
+	}
+
+	if r.Tradable == nil {
+		return errors.New("tradable must be set")
+	}
+
+	if !r.MaxPrice.IsPositive() {
+		return errors.New("max_price must be greater than zero")
+	}
+
+	if !r.MaxPrice.Equal(r.MaxPrice.Round(2)) {
+		return fmt.Errorf("max_price %s has more than 2 decimal places", r.MaxPrice)
+	}
+
+	return nil
+}
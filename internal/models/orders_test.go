@@ -0,0 +1,44 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestPurchaseRequest_Validate(t *testing.T) {
+	tradable := true
+	valid := PurchaseRequest{
+		UserID:         1,
+		MarketHashName: "AK-47 | Redline (Field-Tested)",
+		Tradable:       &tradable,
+		MaxPrice:       decimal.RequireFromString("26.50"),
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(r *PurchaseRequest)
+		wantErr bool
+	}{
+		{name: "valid", modify: func(r *PurchaseRequest) {}},
+		{name: "trailing zeros", modify: func(r *PurchaseRequest) { r.MaxPrice = decimal.RequireFromString("26.500") }},
+		{name: "no user", modify: func(r *PurchaseRequest) { r.UserID = 0 }, wantErr: true},
+		{name: "no name", modify: func(r *PurchaseRequest) { r.MarketHashName = "" }, wantErr: true},
+		{name: "no side", modify: func(r *PurchaseRequest) { r.Tradable = nil }, wantErr: true},
+		{name: "zero max price", modify: func(r *PurchaseRequest) { r.MaxPrice = decimal.Zero }, wantErr: true},
+		{name: "negative max price", modify: func(r *PurchaseRequest) { r.MaxPrice = decimal.NewFromInt(-5) }, wantErr: true},
+		{name: "sub-cent max price", modify: func(r *PurchaseRequest) { r.MaxPrice = decimal.RequireFromString("26.505") }, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := valid
+			tt.modify(&req)
+
+			err := req.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
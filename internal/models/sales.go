@@ -0,0 +1,56 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+type SalesHistory struct {
+	MarketHashName string      `json:"market_hash_name"`
+	Version        string      `json:"version"`
+	Currency       string      `json:"currency"`
+	ItemPage       string      `json:"item_page"`
+	MarketPage     string      `json:"market_page"`
+	Last24Hours    *SalesStats `json:"last_24_hours"`
+	Last7Days      *SalesStats `json:"last_7_days"`
+	Last30Days     *SalesStats `json:"last_30_days"`
+	Last90Days     *SalesStats `json:"last_90_days"`
+}
+
+// SalesStats are aggregated prices and the number of items sold during a period.
+type SalesStats struct {
+	Min    *float64 `json:"min"`
+	Max    *float64 `json:"max"`
+	Avg    *float64 `json:"avg"`
+	Median *float64 `json:"median"`
+	Volume int64    `json:"volume"`
+}
+
+type OutOfStockItem struct {
+	MarketHashName string   `json:"market_hash_name"`
+	Version        string   `json:"version"`
+	Currency       string   `json:"currency"`
+	SuggestedPrice *float64 `json:"suggested_price"`
+	AvgSalePrice   *float64 `json:"avg_sale_price"`
+	SalesLast90d   int64    `json:"sales_last_90d"`
+}
+
+// SalesHistoryRequest selects the sales history of the given items, or of every item when MarketHashNames is empty.
+type SalesHistoryRequest struct {
+	CatalogueParams
+	MarketHashNames []string
+}
+
+func (r SalesHistoryRequest) Validate() error {
+	if len(r.MarketHashNames) > MaxLookupItems {
+		return fmt.Errorf("too many market_hash_names, max %d", MaxLookupItems)
+	}
+
+	for _, name := range r.MarketHashNames {
+		if name == "" {
+			return errors.New("market_hash_name must not be empty")
+		}
+	}
+
+	return nil
+}
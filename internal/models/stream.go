@@ -0,0 +1,77 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// PriceEventType is the SSE event name of a price stream event.
+type PriceEventType string
+
+const (
+	// PriceEventRefresh is sent when a complete catalogue was fetched, with or without changes.
+	PriceEventRefresh PriceEventType = "refresh"
+	// PriceEventPrices carries the price changes of the refresh that match the stream filter.
+	PriceEventPrices PriceEventType = "prices"
+	// PriceEventListing carries listings from the live sale feed that undercut the catalogue min price,
+	// ahead of the refresh that picks them up. Its version is the catalogue version the prices are compared with.
+	PriceEventListing PriceEventType = "listing"
+)
+
+type PriceEvent struct {
+	Type      PriceEventType `json:"-"`
+	AppID     int            `json:"app_id"`
+	Currency  string         `json:"currency"`
+	Version   int64          `json:"version"`
+	FetchedAt time.Time      `json:"fetched_at"`
+	Changes   []ItemChange   `json:"changes,omitempty"`
+}
+
+// PriceStreamFilter selects the price changes a stream gets. Without names every item matches,
+// the price range applies to the new price.
+type PriceStreamFilter struct {
+	CatalogueParams
+	MarketHashNames []string
+	MinPrice        *float64
+	MaxPrice        *float64
+}
+
+func (f PriceStreamFilter) Validate() error {
+	if len(f.MarketHashNames) > MaxLookupItems {
+		return fmt.Errorf("too many market_hash_names, max %d", MaxLookupItems)
+	}
+
+	for _, price := range []*float64{f.MinPrice, f.MaxPrice} {
+		if price != nil && *price < 0 {
+			return errors.New("price must not be negative")
+		}
+	}
+
+	if f.MinPrice != nil && f.MaxPrice != nil && *f.MinPrice > *f.MaxPrice {
+		return errors.New("min_price must not be greater than max_price")
+	}
+
+	return nil
+}
+
+// Match tells whether a change is a price change the filter selects.
+func (f PriceStreamFilter) Match(change ItemChange) bool {
+	if change.Type != ItemChangePriceUp && change.Type != ItemChangePriceDown {
+		return false
+	}
+
+	if len(f.MarketHashNames) > 0 && !slices.Contains(f.MarketHashNames, change.MarketHashName) {
+		return false
+	}
+
+	if f.MinPrice != nil && *change.NewPrice < *f.MinPrice {
+		return false
+	}
+	if f.MaxPrice != nil && *change.NewPrice > *f.MaxPrice {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,275 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"backend-test-golang/internal/models"
+	errs "backend-test-golang/pkg/errors"
+
+	"github.com/lib/pq"
+)
+
+const alertColumns = `id, user_id, app_id, currency, market_hash_name, side, condition, threshold,
+	webhook_url, webhook_secret, active, triggered, last_triggered_at, created_at, updated_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAlert(row rowScanner) (*models.PriceAlert, error) {
+	var alert models.PriceAlert
+	err := row.Scan(
+		&alert.ID,
+		&alert.UserID,
+		&alert.AppID,
+		&alert.Currency,
+		&alert.MarketHashName,
+		&alert.Side,
+		&alert.Condition,
+		&alert.Threshold,
+		&alert.WebhookURL,
+		&alert.WebhookSecret,
+		&alert.Active,
+		&alert.Triggered,
+		&alert.LastTriggeredAt,
+		&alert.CreatedAt,
+		&alert.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &alert, nil
+}
+
+// CreateAlert inserts an alert unless the user already has MaxAlertsPerUser of them.
+// The user row stays locked until commit, so concurrent creates for one user are counted one after another.
+func (r *Repository) CreateAlert(ctx context.Context, alert models.PriceAlert) (*models.PriceAlert, error) {
+	var created *models.PriceAlert
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		if _, err := lockBalance(ctx, tx, alert.UserID); err != nil {
+			return err
+		}
+
+		var count int
+		err := tx.QueryRowContext(ctx, `select count(*) from price_alerts where user_id = $1`, alert.UserID).Scan(&count)
+		if err != nil {
+			return fmt.Errorf("failed to count alerts: %w", err)
+		}
+		if count >= models.MaxAlertsPerUser {
+			return errs.ErrAlertLimitReached
+		}
+
+		row := tx.QueryRowContext(ctx, `
+			insert into price_alerts (user_id, app_id, currency, market_hash_name, side, condition, threshold, webhook_url, webhook_secret)
+			values ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			returning `+alertColumns,
+			alert.UserID, alert.AppID, alert.Currency, alert.MarketHashName, alert.Side, alert.Condition,
+			alert.Threshold, alert.WebhookURL, alert.WebhookSecret)
+
+		created, err = scanAlert(row)
+		if err != nil {
+			return fmt.Errorf("failed to create alert: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+func (r *Repository) GetAlerts(ctx context.Context, userID int64) ([]*models.PriceAlert, error) {
+	rows, err := r.db.QueryContext(ctx, `select `+alertColumns+` from price_alerts where user_id = $1 order by id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get alerts: %w", err)
+	}
+
+	return scanAlerts(rows)
+}
+
+func (r *Repository) GetAlert(ctx context.Context, userID, alertID int64) (*models.PriceAlert, error) {
+	row := r.db.QueryRowContext(ctx, `select `+alertColumns+` from price_alerts where id = $1 and user_id = $2`, alertID, userID)
+
+	alert, err := scanAlert(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.ErrAlertNotFound
+		}
+		return nil, fmt.Errorf("failed to get alert(%d): %w", alertID, err)
+	}
+
+	return alert, nil
+}
+
+// UpdateAlert changes the given fields. Changing what the alert watches or reactivating it re-arms the alert.
+func (r *Repository) UpdateAlert(ctx context.Context, in models.UpdateAlertRequest) (*models.PriceAlert, error) {
+	row := r.db.QueryRowContext(ctx, `
+		update price_alerts
+		set side = coalesce($3, side),
+		    condition = coalesce($4, condition),
+		    threshold = coalesce($5, threshold),
+		    webhook_url = coalesce($6, webhook_url),
+		    active = coalesce($7, active),
+		    triggered = triggered and $3 is null and $4 is null and $5 is null and $7 is null,
+		    updated_at = NOW()
+		where id = $1 and user_id = $2
+		returning `+alertColumns,
+		in.ID, in.UserID, in.Side, in.Condition, in.Threshold, in.WebhookURL, in.Active)
+
+	alert, err := scanAlert(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.ErrAlertNotFound
+		}
+		return nil, fmt.Errorf("failed to update alert(%d): %w", in.ID, err)
+	}
+
+	return alert, nil
+}
+
+func (r *Repository) DeleteAlert(ctx context.Context, userID, alertID int64) error {
+	res, err := r.db.ExecContext(ctx, `delete from price_alerts where id = $1 and user_id = $2`, alertID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete alert(%d): %w", alertID, err)
+	}
+
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete alert(%d): %w", alertID, err)
+	}
+	if deleted == 0 {
+		return errs.ErrAlertNotFound
+	}
+
+	return nil
+}
+
+// GetActiveAlerts returns the alerts to evaluate against a refreshed catalogue.
+func (r *Repository) GetActiveAlerts(ctx context.Context, appID int, currency string) ([]*models.PriceAlert, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		select `+alertColumns+`
+		from price_alerts
+		where app_id = $1 and currency = $2 and active
+	`, appID, currency)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active alerts: %w", err)
+	}
+
+	return scanAlerts(rows)
+}
+
+// MarkAlertsTriggered sets the triggered flag of the alerts that do not have it yet and returns their ids.
+// Only the returned alerts must be delivered, so concurrent evaluations do not send an alert twice.
+func (r *Repository) MarkAlertsTriggered(ctx context.Context, alertIDs []int64) ([]int64, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		update price_alerts
+		set triggered = true, last_triggered_at = NOW()
+		where id = any($1) and active and not triggered
+		returning id
+	`, pq.Array(alertIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark alerts triggered: %w", err)
+	}
+	defer rows.Close()
+
+	var triggered []int64
+	for rows.Next() {
+		var id int64
+		if err = rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan alert id: %w", err)
+		}
+		triggered = append(triggered, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to mark alerts triggered: rows.Err: %w", err)
+	}
+
+	return triggered, nil
+}
+
+// ResetAlerts re-arms alerts whose condition is no longer met.
+func (r *Repository) ResetAlerts(ctx context.Context, alertIDs []int64) error {
+	_, err := r.db.ExecContext(ctx, `update price_alerts set triggered = false where id = any($1) and triggered`, pq.Array(alertIDs))
+	if err != nil {
+		return fmt.Errorf("failed to reset alerts: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Repository) InsertAlertDelivery(ctx context.Context, d models.AlertDelivery) error {
+	_, err := r.db.ExecContext(ctx, `
+		insert into alert_deliveries (alert_id, event_id, price, attempts, status_code, error, success)
+		values ($1, $2, $3, $4, $5, $6, $7)
+	`, d.AlertID, d.EventID, d.Price, d.Attempts, d.StatusCode, d.Error, d.Success)
+	if err != nil {
+		return fmt.Errorf("failed to insert delivery of alert(%d): %w", d.AlertID, err)
+	}
+
+	return nil
+}
+
+// GetAlertDeliveries returns the latest deliveries of a user's alert, newest first.
+func (r *Repository) GetAlertDeliveries(ctx context.Context, userID, alertID int64, limit int) ([]*models.AlertDelivery, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		select d.id, d.alert_id, d.event_id, d.price, d.attempts, d.status_code, d.error, d.success, d.created_at
+		from alert_deliveries d
+		join price_alerts a on a.id = d.alert_id
+		where d.alert_id = $1 and a.user_id = $2
+		order by d.created_at desc, d.id desc
+		limit $3
+	`, alertID, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deliveries of alert(%d): %w", alertID, err)
+	}
+	defer rows.Close()
+
+	deliveries := make([]*models.AlertDelivery, 0)
+	for rows.Next() {
+		var d models.AlertDelivery
+		if err = rows.Scan(&d.ID,
+			&d.AlertID,
+			&d.EventID,
+			&d.Price,
+			&d.Attempts,
+			&d.StatusCode,
+			&d.Error,
+			&d.Success,
+			&d.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan alert delivery: %w", err)
+		}
+		deliveries = append(deliveries, &d)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get deliveries of alert(%d): rows.Err: %w", alertID, err)
+	}
+
+	return deliveries, nil
+}
+
+func scanAlerts(rows *sql.Rows) ([]*models.PriceAlert, error) {
+	defer rows.Close()
+
+	alerts := make([]*models.PriceAlert, 0)
+	for rows.Next() {
+		alert, err := scanAlert(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan alert: %w", err)
+		}
+		alerts = append(alerts, alert)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan alerts: rows.Err: %w", err)
+	}
+
+	return alerts, nil
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"backend-test-golang/internal/models"
+	errs "backend-test-golang/pkg/errors"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestCreateAlert_ConcurrentLimit(t *testing.T) {
+	repo := testRepository(t)
+	ctx := context.Background()
+
+	userID := createTestUser(t, repo, 0)
+	alert := models.PriceAlert{
+		UserID:         userID,
+		AppID:          730,
+		Currency:       "EUR",
+		MarketHashName: "AK-47 | Redline (Field-Tested)",
+		Side:           models.AlertSideTradable,
+		Condition:      models.AlertConditionBelow,
+		Threshold:      decimal.NewFromInt(10),
+		WebhookURL:     "https://example.com/hooks",
+		WebhookSecret:  "secret",
+	}
+
+	attempts := models.MaxAlertsPerUser + 10
+	results := make(chan error, attempts)
+	for range attempts {
+		go func() {
+			_, err := repo.CreateAlert(ctx, alert)
+			results <- err
+		}()
+	}
+
+	var created int
+	for range attempts {
+		err := <-results
+		switch {
+		case err == nil:
+			created++
+		case !errors.Is(err, errs.ErrAlertLimitReached):
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+
+	if created != models.MaxAlertsPerUser {
+		t.Errorf("got %d alerts, want %d", created, models.MaxAlertsPerUser)
+	}
+}
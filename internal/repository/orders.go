@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"backend-test-golang/internal/models"
+	errs "backend-test-golang/pkg/errors"
+)
+
+// CreateOrder debits order.Price from the user balance and stores the order in the same database transaction,
+// so an order never exists without its payment and vice versa.
+func (r *Repository) CreateOrder(ctx context.Context, idempotencyKey string, order models.Order) (*models.Order, error) {
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		payment, err := withdraw(ctx, tx, models.WithdrawRequest{
+			IdempotencyKey: idempotencyKey,
+			Amount:         order.Price,
+			UserID:         order.UserID,
+		}, models.TransactionTypePurchase)
+		if err != nil {
+			return err
+		}
+
+		err = tx.QueryRowContext(ctx, `
+			insert into orders (user_id, transaction_id, app_id, currency, market_hash_name, tradable, price, max_price)
+			values ($1, $2, $3, $4, $5, $6, $7, $8)
+			returning id, created_at
+		`, order.UserID, payment.ID, order.AppID, order.Currency, order.MarketHashName,
+			order.Tradable, order.Price, order.MaxPrice).Scan(&order.ID, &order.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to create order: %w", err)
+		}
+
+		order.Transaction = payment
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &order, nil
+}
+
+// GetOrderByIdempotencyKey returns the order paid by the transaction with idempotencyKey.
+func (r *Repository) GetOrderByIdempotencyKey(ctx context.Context, idempotencyKey string) (*models.Order, error) {
+	var (
+		order models.Order
+		tx    models.Transaction
+	)
+
+	err := r.db.QueryRowContext(ctx, `
+		select o.id, o.user_id, o.app_id, o.currency, o.market_hash_name, o.tradable, o.price, o.max_price, o.created_at,
+			t.id, t.idempotency_key, t.user_id, t.type, t.reference_transaction_id, t.balance_before, t.balance_after, t.amount, t.created_at
+		from orders o
+		join transactions t on t.id = o.transaction_id
+		where t.idempotency_key = $1
+	`, idempotencyKey).Scan(
+		&order.ID,
+		&order.UserID,
+		&order.AppID,
+		&order.Currency,
+		&order.MarketHashName,
+		&order.Tradable,
+		&order.Price,
+		&order.MaxPrice,
+		&order.CreatedAt,
+		&tx.ID,
+		&tx.IdempotencyKey,
+		&tx.UserID,
+		&tx.Type,
+		&tx.ReferenceTransactionID,
+		&tx.BalanceBefore,
+		&tx.BalanceAfter,
+		&tx.Amount,
+		&tx.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, errs.ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+
+	order.Transaction = &tx
+	return &order, nil
+}
@@ -10,6 +10,7 @@ import (
 	"backend-test-golang/pkg/database"
 	errs "backend-test-golang/pkg/errors"
 
+	"github.com/lib/pq"
 	"github.com/shopspring/decimal"
 )
 
@@ -21,58 +22,255 @@ func New(db *database.DB) *Repository {
 	return &Repository{db: db}
 }
 
-func (r *Repository) Withdraw(ctx context.Context, in models.WithdrawRequest) (*models.Transaction, error) {
-	tx, err := r.db.Begin()
+// inTx runs fn inside a database transaction and commits it when fn succeeds.
+func (r *Repository) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
-	var currentBalance decimal.Decimal
-	err = tx.QueryRowContext(ctx, "SELECT balance FROM users WHERE id = $1 FOR UPDATE", in.UserID).Scan(&currentBalance)
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, errs.ErrUserNotFound
-		}
-		return nil, fmt.Errorf("failed to get current balance for user(%d): %w", in.UserID, err)
+	if err = fn(tx); err != nil {
+		return err
 	}
 
-	newBalance := currentBalance.Sub(in.Amount)
-	if newBalance.IsNegative() {
-		return nil, fmt.Errorf("amount(%s) is greater than current balance(%s): %w", in.Amount.String(), currentBalance.String(), errs.ErrInsufficientBalance)
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	_, err = tx.Exec("UPDATE users SET balance = $1 WHERE id = $2", newBalance, in.UserID)
+	return nil
+}
+
+func (r *Repository) Withdraw(ctx context.Context, in models.WithdrawRequest) (*models.Transaction, error) {
+	var txRecord *models.Transaction
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		txRecord, err = withdraw(ctx, tx, in, models.TransactionTypeWithdrawal)
+		return err
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to update user(%d): %w", in.UserID, err)
+		return nil, err
 	}
 
-	row := tx.QueryRowContext(ctx,
-		`
-			INSERT INTO transactions (idempotency_key, user_id, balance_before, balance_after, amount, created_at)
-			VALUES ($1, $2, $3, $4, $5, NOW())
-			RETURNING id, idempotency_key, user_id, balance_before, balance_after, amount, created_at
-		`, in.IdempotencyKey, in.UserID, currentBalance, newBalance, in.Amount)
+	return txRecord, nil
+}
+
+// uniqueViolation is the Postgres error code of a unique constraint violation, here of transactions.idempotency_key.
+const uniqueViolation = "23505"
 
+const transactionColumns = `id, idempotency_key, user_id, type, reference_transaction_id, balance_before, balance_after, amount, created_at`
+
+func scanTransaction(row rowScanner) (*models.Transaction, error) {
 	var txRecord models.Transaction
-	err = row.Scan(
+	err := row.Scan(
 		&txRecord.ID,
 		&txRecord.IdempotencyKey,
 		&txRecord.UserID,
+		&txRecord.Type,
+		&txRecord.ReferenceTransactionID,
 		&txRecord.BalanceBefore,
 		&txRecord.BalanceAfter,
 		&txRecord.Amount,
 		&txRecord.CreatedAt,
 	)
 	if err != nil {
+		return nil, err
+	}
+
+	return &txRecord, nil
+}
+
+// withdraw debits the user balance and records the transaction in tx. The user row stays locked until tx ends.
+func withdraw(ctx context.Context, tx *sql.Tx, in models.WithdrawRequest, txType models.TransactionType) (*models.Transaction, error) {
+	if !in.Amount.IsPositive() {
+		return nil, fmt.Errorf("amount(%s) must be positive: %w", in.Amount.String(), errs.ErrValidationFailed)
+	}
+
+	currentBalance, err := lockBalance(ctx, tx, in.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkKeyUnused(ctx, tx, in.IdempotencyKey); err != nil {
+		return nil, err
+	}
+
+	newBalance := currentBalance.Sub(in.Amount)
+	if newBalance.IsNegative() {
+		return nil, fmt.Errorf("amount(%s) is greater than current balance(%s): %w", in.Amount.String(), currentBalance.String(), errs.ErrInsufficientBalance)
+	}
+
+	return recordTransaction(ctx, tx, models.Transaction{
+		IdempotencyKey: in.IdempotencyKey,
+		UserID:         in.UserID,
+		Type:           txType,
+		BalanceBefore:  currentBalance,
+		BalanceAfter:   newBalance,
+		Amount:         in.Amount,
+	})
+}
+
+// lockBalance returns the user balance and locks the user row until tx ends.
+func lockBalance(ctx context.Context, tx *sql.Tx, userID int64) (decimal.Decimal, error) {
+	var balance decimal.Decimal
+	err := tx.QueryRowContext(ctx, "SELECT balance FROM users WHERE id = $1 FOR UPDATE", userID).Scan(&balance)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return decimal.Zero, errs.ErrUserNotFound
+		}
+		return decimal.Zero, fmt.Errorf("failed to get current balance for user(%d): %w", userID, err)
+	}
+
+	return balance, nil
+}
+
+// checkKeyUnused fails with ErrIdempotencyKeyUsed if a transaction with idempotencyKey exists. Called with the user row
+// locked, it sees a concurrent request with the same key that committed while we waited for the lock, which would
+// otherwise surface as a balance error computed from the balance it already changed.
+func checkKeyUnused(ctx context.Context, tx *sql.Tx, idempotencyKey string) error {
+	var exists bool
+	err := tx.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM transactions WHERE idempotency_key = $1)", idempotencyKey).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("failed to check key %q: %w", idempotencyKey, err)
+	}
+	if exists {
+		return fmt.Errorf("key %q: %w", idempotencyKey, errs.ErrIdempotencyKeyUsed)
+	}
+
+	return nil
+}
+
+// recordTransaction sets the user balance to t.BalanceAfter and stores t. The user row must be locked by tx.
+func recordTransaction(ctx context.Context, tx *sql.Tx, t models.Transaction) (*models.Transaction, error) {
+	_, err := tx.ExecContext(ctx, "UPDATE users SET balance = $1, updated_at = NOW() WHERE id = $2", t.BalanceAfter, t.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update user(%d): %w", t.UserID, err)
+	}
+
+	row := tx.QueryRowContext(ctx,
+		`
+			INSERT INTO transactions (idempotency_key, user_id, type, reference_transaction_id, balance_before, balance_after, amount, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())
+			RETURNING `+transactionColumns,
+		t.IdempotencyKey, t.UserID, t.Type, t.ReferenceTransactionID, t.BalanceBefore, t.BalanceAfter, t.Amount)
+
+	txRecord, err := scanTransaction(row)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			// A concurrent request with the same key has committed first.
+			return nil, fmt.Errorf("key %q: %w", t.IdempotencyKey, errs.ErrIdempotencyKeyUsed)
+		}
 		return nil, fmt.Errorf("failed to create transaction: %w", err)
 	}
 
-	if err = tx.Commit(); err != nil {
-		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	return txRecord, nil
+}
+
+// Deposit credits the user balance. A deposit that would not fit the balance column is rejected.
+func (r *Repository) Deposit(ctx context.Context, in models.DepositRequest) (*models.Transaction, error) {
+	var txRecord *models.Transaction
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		currentBalance, err := lockBalance(ctx, tx, in.UserID)
+		if err != nil {
+			return err
+		}
+		if err = checkKeyUnused(ctx, tx, in.IdempotencyKey); err != nil {
+			return err
+		}
+
+		newBalance := currentBalance.Add(in.Amount)
+		if newBalance.GreaterThan(models.MaxBalance) {
+			return fmt.Errorf("balance(%s) after deposit(%s) is greater than %s: %w", currentBalance.String(), in.Amount.String(), models.MaxBalance.String(), errs.ErrBalanceLimitExceeded)
+		}
+
+		txRecord, err = recordTransaction(ctx, tx, models.Transaction{
+			IdempotencyKey: in.IdempotencyKey,
+			UserID:         in.UserID,
+			Type:           models.TransactionTypeDeposit,
+			BalanceBefore:  currentBalance,
+			BalanceAfter:   newBalance,
+			Amount:         in.Amount,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return &txRecord, nil
+	return txRecord, nil
+}
+
+// Refund credits back a debit transaction, fully or in part. The user row lock serializes refunds of the same
+// transaction, so their sum never exceeds the original amount.
+func (r *Repository) Refund(ctx context.Context, in models.RefundRequest) (*models.Transaction, error) {
+	var txRecord *models.Transaction
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		var (
+			userID int64
+			txType models.TransactionType
+			amount decimal.Decimal
+		)
+		err := tx.QueryRowContext(ctx, "SELECT user_id, type, amount FROM transactions WHERE id = $1", in.TransactionID).
+			Scan(&userID, &txType, &amount)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return errs.ErrTransactionNotFound
+			}
+			return fmt.Errorf("failed to get transaction(%d): %w", in.TransactionID, err)
+		}
+
+		if !txType.Refundable() {
+			return fmt.Errorf("%s transaction(%d): %w", txType, in.TransactionID, errs.ErrNotRefundable)
+		}
+
+		currentBalance, err := lockBalance(ctx, tx, userID)
+		if err != nil {
+			return err
+		}
+		if err = checkKeyUnused(ctx, tx, in.IdempotencyKey); err != nil {
+			return err
+		}
+
+		var refunded decimal.Decimal
+		err = tx.QueryRowContext(ctx,
+			"SELECT COALESCE(SUM(amount), 0) FROM transactions WHERE reference_transaction_id = $1 AND type = $2",
+			in.TransactionID, models.TransactionTypeRefund).Scan(&refunded)
+		if err != nil {
+			return fmt.Errorf("failed to get refunds of transaction(%d): %w", in.TransactionID, err)
+		}
+
+		remaining := amount.Sub(refunded)
+		refund := in.Amount
+		if refund.IsZero() {
+			refund = remaining
+		}
+
+		if !remaining.IsPositive() || refund.GreaterThan(remaining) {
+			return fmt.Errorf("refund(%s) of transaction(%d) is greater than the refundable amount(%s): %w",
+				refund.String(), in.TransactionID, remaining.String(), errs.ErrRefundExceedsAmount)
+		}
+
+		newBalance := currentBalance.Add(refund)
+		if newBalance.GreaterThan(models.MaxBalance) {
+			return fmt.Errorf("balance(%s) after refund(%s) is greater than %s: %w", currentBalance.String(), refund.String(), models.MaxBalance.String(), errs.ErrBalanceLimitExceeded)
+		}
+
+		txRecord, err = recordTransaction(ctx, tx, models.Transaction{
+			IdempotencyKey:         in.IdempotencyKey,
+			UserID:                 userID,
+			Type:                   models.TransactionTypeRefund,
+			ReferenceTransactionID: &in.TransactionID,
+			BalanceBefore:          currentBalance,
+			BalanceAfter:           newBalance,
+			Amount:                 refund,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return txRecord, nil
 }
 
 func (r *Repository) GetUser(ctx context.Context, userID int64) (models.User, error) {
@@ -93,7 +291,7 @@ func (r *Repository) GetUser(ctx context.Context, userID int64) (models.User, er
 
 func (r *Repository) GetTransactions(ctx context.Context, userID int64) ([]*models.Transaction, error) {
 	rows, err := r.db.QueryContext(ctx, `
-		select id, idempotency_key, user_id, balance_before, balance_after, amount, created_at
+		select `+transactionColumns+`
 		from transactions 
 		where user_id = $1
 	`, userID)
@@ -107,19 +305,12 @@ func (r *Repository) GetTransactions(ctx context.Context, userID int64) ([]*mode
 
 	var transactions []*models.Transaction
 	for rows.Next() {
-		var tx models.Transaction
-		if err = rows.Scan(&tx.ID,
-			&tx.IdempotencyKey,
-			&tx.UserID,
-			&tx.BalanceBefore,
-			&tx.BalanceAfter,
-			&tx.Amount,
-			&tx.CreatedAt,
-		); err != nil {
+		tx, err := scanTransaction(rows)
+		if err != nil {
 			return nil, fmt.Errorf("failed to scan transaction: %w", err)
 		}
 
-		transactions = append(transactions, &tx)
+		transactions = append(transactions, tx)
 	}
 
 	if err = rows.Err(); err != nil {
@@ -130,21 +321,11 @@ func (r *Repository) GetTransactions(ctx context.Context, userID int64) ([]*mode
 }
 
 func (r *Repository) GetTransactionByIdempotencyKey(ctx context.Context, idempotencyKey string) (*models.Transaction, error) {
-	var tx models.Transaction
-
-	err := r.db.QueryRowContext(ctx, `
-		select id, idempotency_key, user_id, balance_before, balance_after, amount, created_at
+	tx, err := scanTransaction(r.db.QueryRowContext(ctx, `
+		select `+transactionColumns+`
 		from transactions 
 		where idempotency_key = $1
-	`, idempotencyKey).Scan(&tx.ID,
-		&tx.IdempotencyKey,
-		&tx.UserID,
-		&tx.BalanceBefore,
-		&tx.BalanceAfter,
-		&tx.Amount,
-		&tx.CreatedAt,
-	)
-
+	`, idempotencyKey))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, errs.ErrNotFound
@@ -152,5 +333,5 @@ func (r *Repository) GetTransactionByIdempotencyKey(ctx context.Context, idempot
 		return nil, fmt.Errorf("failed to get transactions: %w", err)
 	}
 
-	return &tx, nil
+	return tx, nil
 }
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"backend-test-golang/internal/models"
+	errs "backend-test-golang/pkg/errors"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// sameKeyConcurrently runs op attempts times at once and returns how many succeeded,
+// failing the test on any error other than ErrIdempotencyKeyUsed.
+func sameKeyConcurrently(t *testing.T, attempts int, op func() error) int {
+	t.Helper()
+
+	results := make(chan error, attempts)
+	for range attempts {
+		go func() {
+			results <- op()
+		}()
+	}
+
+	var succeeded int
+	for range attempts {
+		err := <-results
+		switch {
+		case err == nil:
+			succeeded++
+		case !errors.Is(err, errs.ErrIdempotencyKeyUsed):
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+
+	return succeeded
+}
+
+func TestWithdraw_SameIdempotencyKey(t *testing.T) {
+	repo := testRepository(t)
+	ctx := context.Background()
+
+	// The whole balance is withdrawn, so a request that ignored the key would fail on the balance instead.
+	userID := createTestUser(t, repo, 30)
+	req := models.WithdrawRequest{
+		IdempotencyKey: uuid.NewString(),
+		UserID:         userID,
+		Amount:         decimal.NewFromInt(30),
+	}
+
+	succeeded := sameKeyConcurrently(t, 10, func() error {
+		_, err := repo.Withdraw(ctx, req)
+		return err
+	})
+	if succeeded != 1 {
+		t.Errorf("got %d withdrawals, want exactly one", succeeded)
+	}
+	if got := balanceOf(t, repo, userID); !got.IsZero() {
+		t.Errorf("got balance %s, want 0", got)
+	}
+}
+
+func TestRefund_SameIdempotencyKey(t *testing.T) {
+	repo := testRepository(t)
+	ctx := context.Background()
+
+	userID := createTestUser(t, repo, 100)
+	withdrawal, err := repo.Withdraw(ctx, models.WithdrawRequest{
+		IdempotencyKey: uuid.NewString(),
+		UserID:         userID,
+		Amount:         decimal.NewFromInt(30),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A full refund: a request that ignored the key would find nothing left to refund instead.
+	req := models.RefundRequest{
+		IdempotencyKey: uuid.NewString(),
+		TransactionID:  withdrawal.ID,
+	}
+
+	succeeded := sameKeyConcurrently(t, 10, func() error {
+		_, err := repo.Refund(ctx, req)
+		return err
+	})
+	if succeeded != 1 {
+		t.Errorf("got %d refunds, want exactly one", succeeded)
+	}
+	if got := balanceOf(t, repo, userID); !got.Equal(decimal.NewFromInt(100)) {
+		t.Errorf("got balance %s, want 100", got)
+	}
+
+	refund, err := repo.GetTransactionByIdempotencyKey(ctx, req.IdempotencyKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refund.Type != models.TransactionTypeRefund || !refund.Amount.Equal(withdrawal.Amount) ||
+		refund.ReferenceTransactionID == nil || *refund.ReferenceTransactionID != withdrawal.ID {
+		t.Errorf("got %+v, want a full refund of transaction(%d)", refund, withdrawal.ID)
+	}
+}
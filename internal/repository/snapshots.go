@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"backend-test-golang/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// InsertPriceSnapshots bulk inserts the prices of a catalogue with COPY in one transaction.
+func (r *Repository) InsertPriceSnapshots(ctx context.Context, params models.CatalogueParams, fetchedAt time.Time, items []*models.ItemResponse) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn("item_price_snapshots",
+		"app_id",
+		"currency",
+		"market_hash_name",
+		"suggested_price",
+		"min_price_tradable",
+		"min_price_non_tradable",
+		"quantity_tradable",
+		"quantity_non_tradable",
+		"fetched_at",
+	))
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare copy: %w", err)
+	}
+	defer stmt.Close()
+
+	fetchedAt = fetchedAt.UTC()
+	for _, item := range items {
+		var quantityTradable, quantityNonTradable int64
+		if item.Tradable != nil {
+			quantityTradable = item.Tradable.Quantity
+		}
+		if item.NonTradable != nil {
+			quantityNonTradable = item.NonTradable.Quantity
+		}
+
+		_, err = stmt.ExecContext(ctx,
+			params.AppID,
+			params.Currency,
+			item.MarketHashName,
+			item.SuggestedPrice,
+			item.MinPriceTradable,
+			item.MinPriceNonTradable,
+			quantityTradable,
+			quantityNonTradable,
+			fetchedAt,
+		)
+		if err != nil {
+			return 0, fmt.Errorf("failed to copy snapshot of %q: %w", item.MarketHashName, err)
+		}
+	}
+
+	// Flushes the buffered rows.
+	if _, err = stmt.ExecContext(ctx); err != nil {
+		return 0, fmt.Errorf("failed to copy snapshots: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return int64(len(items)), nil
+}
+
+// DeletePriceSnapshotsBefore removes snapshots fetched before the given time.
+func (r *Repository) DeletePriceSnapshotsBefore(ctx context.Context, before time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `delete from item_price_snapshots where fetched_at < $1`, before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete price snapshots: %w", err)
+	}
+
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete price snapshots: %w", err)
+	}
+
+	return deleted, nil
+}
+
+// GetPriceHistory aggregates the lowest prices of an item per time bucket.
+func (r *Repository) GetPriceHistory(ctx context.Context, q models.PriceHistoryQuery) ([]*models.PriceHistoryPoint, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		select date_trunc($1, fetched_at) as bucket,
+		       min(min_price_tradable), round(avg(min_price_tradable), 2), max(min_price_tradable),
+		       min(min_price_non_tradable), round(avg(min_price_non_tradable), 2), max(min_price_non_tradable),
+		       count(*)
+		from item_price_snapshots
+		where app_id = $2 and currency = $3 and market_hash_name = $4
+		  and fetched_at >= $5 and fetched_at < $6
+		group by bucket
+		order by bucket
+	`, string(q.Granularity), q.AppID, q.Currency, q.MarketHashName, q.From.UTC(), q.To.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get price history: %w", err)
+	}
+	defer rows.Close()
+
+	points := make([]*models.PriceHistoryPoint, 0)
+	for rows.Next() {
+		var point models.PriceHistoryPoint
+		if err = rows.Scan(&point.Time,
+			&point.Tradable.Min,
+			&point.Tradable.Avg,
+			&point.Tradable.Max,
+			&point.NonTradable.Min,
+			&point.NonTradable.Avg,
+			&point.NonTradable.Max,
+			&point.Snapshots,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan price history: %w", err)
+		}
+
+		point.Time = point.Time.UTC()
+		points = append(points, &point)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get price history: rows.Err: %w", err)
+	}
+
+	return points, nil
+}
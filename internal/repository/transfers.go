@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"backend-test-golang/internal/models"
+	errs "backend-test-golang/pkg/errors"
+
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
+)
+
+// Transfer moves funds between two users in one database transaction. Both user rows are locked in id order,
+// so transfers in opposite directions wait for each other instead of deadlocking.
+func (r *Repository) Transfer(ctx context.Context, in models.TransferRequest) (*models.Transfer, error) {
+	var transfer models.Transfer
+	err := r.inTx(ctx, func(tx *sql.Tx) error {
+		balances, err := lockBalances(ctx, tx, in.FromUserID, in.ToUserID)
+		if err != nil {
+			return err
+		}
+		if err = checkKeyUnused(ctx, tx, in.IdempotencyKey); err != nil {
+			return err
+		}
+
+		fromBalance, toBalance := balances[in.FromUserID], balances[in.ToUserID]
+
+		newFromBalance := fromBalance.Sub(in.Amount)
+		if newFromBalance.IsNegative() {
+			return fmt.Errorf("amount(%s) is greater than current balance(%s): %w", in.Amount.String(), fromBalance.String(), errs.ErrInsufficientBalance)
+		}
+
+		newToBalance := toBalance.Add(in.Amount)
+		if newToBalance.GreaterThan(models.MaxBalance) {
+			return fmt.Errorf("balance(%s) after transfer(%s) is greater than %s: %w", toBalance.String(), in.Amount.String(), models.MaxBalance.String(), errs.ErrBalanceLimitExceeded)
+		}
+
+		transfer.Debit, err = recordTransaction(ctx, tx, models.Transaction{
+			IdempotencyKey: in.IdempotencyKey,
+			UserID:         in.FromUserID,
+			Type:           models.TransactionTypeTransferOut,
+			BalanceBefore:  fromBalance,
+			BalanceAfter:   newFromBalance,
+			Amount:         in.Amount,
+		})
+		if err != nil {
+			return err
+		}
+
+		transfer.Credit, err = recordTransaction(ctx, tx, models.Transaction{
+			IdempotencyKey:         in.CreditIdempotencyKey(),
+			UserID:                 in.ToUserID,
+			Type:                   models.TransactionTypeTransferIn,
+			ReferenceTransactionID: &transfer.Debit.ID,
+			BalanceBefore:          toBalance,
+			BalanceAfter:           newToBalance,
+			Amount:                 in.Amount,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &transfer, nil
+}
+
+// lockBalances returns the balances of users and locks their rows until tx ends. Rows are always locked
+// in id order, whatever the order of userIDs.
+func lockBalances(ctx context.Context, tx *sql.Tx, userIDs ...int64) (map[int64]decimal.Decimal, error) {
+	rows, err := tx.QueryContext(ctx, "SELECT id, balance FROM users WHERE id = ANY($1) ORDER BY id FOR UPDATE", pq.Array(userIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current balances of users%v: %w", userIDs, err)
+	}
+	defer rows.Close()
+
+	balances := make(map[int64]decimal.Decimal, len(userIDs))
+	for rows.Next() {
+		var (
+			id      int64
+			balance decimal.Decimal
+		)
+		if err = rows.Scan(&id, &balance); err != nil {
+			return nil, fmt.Errorf("failed to scan balance: %w", err)
+		}
+
+		balances[id] = balance
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get current balances: rows.Err: %w", err)
+	}
+
+	for _, id := range userIDs {
+		if _, ok := balances[id]; !ok {
+			return nil, fmt.Errorf("user(%d): %w", id, errs.ErrUserNotFound)
+		}
+	}
+
+	return balances, nil
+}
+
+// GetTransferByIdempotencyKey returns the transfer whose debit was made with idempotencyKey.
+func (r *Repository) GetTransferByIdempotencyKey(ctx context.Context, idempotencyKey string) (*models.Transfer, error) {
+	debit, err := r.GetTransactionByIdempotencyKey(ctx, idempotencyKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if debit.Type != models.TransactionTypeTransferOut {
+		return nil, fmt.Errorf("%s transaction(%d): %w", debit.Type, debit.ID, errs.ErrIdempotencyKeyUsed)
+	}
+
+	credit, err := scanTransaction(r.db.QueryRowContext(ctx, `
+		select `+transactionColumns+`
+		from transactions
+		where reference_transaction_id = $1 and type = $2
+	`, debit.ID, models.TransactionTypeTransferIn))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("credit of transfer(%d): %w", debit.ID, errs.ErrTransactionNotFound)
+		}
+		return nil, fmt.Errorf("failed to get credit of transfer(%d): %w", debit.ID, err)
+	}
+
+	return &models.Transfer{Debit: debit, Credit: credit}, nil
+}
@@ -0,0 +1,265 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"backend-test-golang/internal/models"
+	"backend-test-golang/pkg/database"
+	errs "backend-test-golang/pkg/errors"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
+)
+
+// testRepository connects to the scratch database in TEST_DB_URL and applies the migrations to it.
+// Tests that need Postgres are skipped without it.
+func testRepository(t *testing.T) *Repository {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DB_URL")
+	if dsn == "" {
+		t.Skip("TEST_DB_URL is not set")
+	}
+
+	db, err := database.Connect(dsn)
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	// init.sql creates the tables the numbered migrations build on.
+	migrations, err := filepath.Glob("../../migrations/0*.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range append([]string{"../../migrations/init.sql"}, migrations...) {
+		script, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = db.Exec(string(script)); err != nil {
+			t.Fatalf("failed to apply %s: %v", file, err)
+		}
+	}
+
+	return New(db)
+}
+
+func createTestUser(t *testing.T, repo *Repository, balance int64) int64 {
+	t.Helper()
+
+	var id int64
+	err := repo.db.QueryRow("insert into users (balance) values ($1) returning id", balance).Scan(&id)
+	if err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	return id
+}
+
+func balanceOf(t *testing.T, repo *Repository, userID int64) decimal.Decimal {
+	t.Helper()
+
+	user, err := repo.GetUser(context.Background(), userID)
+	if err != nil {
+		t.Fatalf("failed to get user(%d): %v", userID, err)
+	}
+	return user.Balance
+}
+
+func TestTransfer_Concurrent(t *testing.T) {
+	repo := testRepository(t)
+	ctx := context.Background()
+
+	const (
+		users              = 4
+		initialBalance     = 100
+		workers            = 8
+		transfersPerWorker = 50
+	)
+
+	ids := make([]int64, users)
+	for i := range ids {
+		ids[i] = createTestUser(t, repo, initialBalance)
+	}
+
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			rnd := rand.New(rand.NewSource(int64(w)))
+			for i := range transfersPerWorker {
+				// Every other transfer goes between the first two users in alternating directions,
+				// the case that deadlocks when rows are locked in request order.
+				from, to := ids[0], ids[1]
+				if i%2 == 1 {
+					from, to = ids[rnd.Intn(users)], ids[rnd.Intn(users)]
+					for to == from {
+						to = ids[rnd.Intn(users)]
+					}
+				} else if w%2 == 1 {
+					from, to = to, from
+				}
+
+				_, err := repo.Transfer(ctx, models.TransferRequest{
+					IdempotencyKey: uuid.NewString(),
+					FromUserID:     from,
+					ToUserID:       to,
+					Amount:         decimal.NewFromInt(int64(1 + rnd.Intn(30))),
+				})
+				if err != nil && !errors.Is(err, errs.ErrInsufficientBalance) {
+					t.Errorf("transfer from user(%d) to user(%d): %v", from, to, err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	total := decimal.Zero
+	for _, id := range ids {
+		balance := balanceOf(t, repo, id)
+		if balance.IsNegative() {
+			t.Errorf("user(%d) has negative balance %s", id, balance)
+		}
+		total = total.Add(balance)
+
+		txs, err := repo.GetTransactions(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := decimal.NewFromInt(initialBalance)
+		for _, tx := range txs {
+			if tx.Type.Credit() {
+				want = want.Add(tx.Amount)
+			} else {
+				want = want.Sub(tx.Amount)
+			}
+		}
+		if !balance.Equal(want) {
+			t.Errorf("user(%d) balance %s does not match its transactions %s", id, balance, want)
+		}
+	}
+
+	if want := decimal.NewFromInt(users * initialBalance); !total.Equal(want) {
+		t.Errorf("got total balance %s, want %s", total, want)
+	}
+
+	var unpaired int
+	err := repo.db.QueryRow(`
+		select count(*)
+		from transactions d
+		where d.type = 'transfer_out' and d.user_id = any($1)
+			and (
+				select count(*)
+				from transactions c
+				where c.reference_transaction_id = d.id and c.type = 'transfer_in' and c.amount = d.amount
+			) <> 1
+	`, pq.Array(ids)).Scan(&unpaired)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unpaired != 0 {
+		t.Errorf("got %d transfer debits without a matching credit", unpaired)
+	}
+}
+
+func TestTransfer_SameIdempotencyKey(t *testing.T) {
+	repo := testRepository(t)
+	ctx := context.Background()
+
+	// The whole balance is sent, so a request that ignored the key would fail on the balance instead.
+	from := createTestUser(t, repo, 10)
+	to := createTestUser(t, repo, 0)
+	req := models.TransferRequest{
+		IdempotencyKey: uuid.NewString(),
+		FromUserID:     from,
+		ToUserID:       to,
+		Amount:         decimal.NewFromInt(10),
+	}
+
+	const attempts = 10
+	results := make(chan error, attempts)
+	for range attempts {
+		go func() {
+			_, err := repo.Transfer(ctx, req)
+			results <- err
+		}()
+	}
+
+	var succeeded int
+	for range attempts {
+		err := <-results
+		switch {
+		case err == nil:
+			succeeded++
+		case !errors.Is(err, errs.ErrIdempotencyKeyUsed):
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+
+	if succeeded != 1 {
+		t.Errorf("got %d transfers, want exactly one", succeeded)
+	}
+	if got := balanceOf(t, repo, from); !got.IsZero() {
+		t.Errorf("got sender balance %s, want 0", got)
+	}
+	if got := balanceOf(t, repo, to); !got.Equal(decimal.NewFromInt(10)) {
+		t.Errorf("got recipient balance %s, want 10", got)
+	}
+
+	transfer, err := repo.GetTransferByIdempotencyKey(ctx, req.IdempotencyKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transfer.Credit.ReferenceTransactionID == nil || *transfer.Credit.ReferenceTransactionID != transfer.Debit.ID {
+		t.Errorf("credit %+v does not reference debit %d", transfer.Credit, transfer.Debit.ID)
+	}
+	if transfer.Credit.IdempotencyKey != req.CreditIdempotencyKey() {
+		t.Errorf("got credit key %q, want %q", transfer.Credit.IdempotencyKey, req.CreditIdempotencyKey())
+	}
+}
+
+func TestTransfer_Rejected(t *testing.T) {
+	repo := testRepository(t)
+	ctx := context.Background()
+
+	from := createTestUser(t, repo, 5)
+	to := createTestUser(t, repo, 0)
+
+	tests := []struct {
+		name    string
+		toUser  int64
+		amount  int64
+		wantErr error
+	}{
+		{name: "insufficient balance", toUser: to, amount: 6, wantErr: errs.ErrInsufficientBalance},
+		{name: "unknown recipient", toUser: to + 1_000_000, amount: 1, wantErr: errs.ErrUserNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := repo.Transfer(ctx, models.TransferRequest{
+				IdempotencyKey: uuid.NewString(),
+				FromUserID:     from,
+				ToUserID:       tt.toUser,
+				Amount:         decimal.NewFromInt(tt.amount),
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+
+			if got := balanceOf(t, repo, from); !got.Equal(decimal.NewFromInt(5)) {
+				t.Errorf("got sender balance %s, want it unchanged", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,299 @@
+package services
+
+import (
+	"backend-test-golang/internal/models"
+	errs "backend-test-golang/pkg/errors"
+	"backend-test-golang/pkg/webhook"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// alertsTimeout bounds evaluating alerts of one catalogue including all webhook deliveries.
+	alertsTimeout = 2 * time.Minute
+	// alertDeliveryConcurrency limits how many webhooks are sent at once.
+	alertDeliveryConcurrency = 8
+	// maxAlertDeliveries is how many of the latest deliveries of an alert are returned.
+	maxAlertDeliveries = 100
+)
+
+// alertMatch is an alert whose condition holds for the current price.
+type alertMatch struct {
+	alert *models.PriceAlert
+	price float64
+}
+
+func (s *Service) CreateAlert(ctx context.Context, in models.CreateAlertRequest) (*models.PriceAlert, error) {
+	params, err := s.catalogueParams(in.CatalogueParams)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = in.Validate(); err == nil {
+		err = webhook.CheckURL(ctx, in.WebhookURL)
+	}
+	if err != nil {
+		err = errors.Join(errs.ErrValidationFailed, err)
+		log.Printf("validation error in create alert: %v", err)
+		return nil, err
+	}
+
+	if _, err = s.repo.GetUser(ctx, in.UserID); err != nil {
+		log.Printf("failed to get user: %v", err)
+		return nil, err
+	}
+
+	secret, err := newWebhookSecret()
+	if err != nil {
+		log.Printf("[ERROR] CreateAlert: %v\n", err)
+		return nil, err
+	}
+
+	alert, err := s.repo.CreateAlert(ctx, models.PriceAlert{
+		UserID:         in.UserID,
+		AppID:          params.AppID,
+		Currency:       params.Currency,
+		MarketHashName: in.MarketHashName,
+		Side:           in.Side,
+		Condition:      in.Condition,
+		Threshold:      in.Threshold,
+		WebhookURL:     in.WebhookURL,
+		WebhookSecret:  secret,
+	})
+	if err != nil {
+		log.Printf("failed to create alert: %v", err)
+		return nil, err
+	}
+
+	return alert, nil
+}
+
+func (s *Service) GetAlerts(ctx context.Context, userID int64) ([]*models.PriceAlert, error) {
+	alerts, err := s.repo.GetAlerts(ctx, userID)
+	if err != nil {
+		log.Printf("failed to get alerts: %v", err)
+		return nil, err
+	}
+
+	for _, alert := range alerts {
+		alert.WebhookSecret = ""
+	}
+
+	return alerts, nil
+}
+
+func (s *Service) GetAlert(ctx context.Context, userID, alertID int64) (*models.PriceAlert, error) {
+	alert, err := s.repo.GetAlert(ctx, userID, alertID)
+	if err != nil {
+		log.Printf("failed to get alert: %v", err)
+		return nil, err
+	}
+
+	alert.WebhookSecret = ""
+	return alert, nil
+}
+
+func (s *Service) UpdateAlert(ctx context.Context, in models.UpdateAlertRequest) (*models.PriceAlert, error) {
+	err := in.Validate()
+	if err == nil && in.WebhookURL != nil {
+		err = webhook.CheckURL(ctx, *in.WebhookURL)
+	}
+	if err != nil {
+		err = errors.Join(errs.ErrValidationFailed, err)
+		log.Printf("validation error in update alert: %v", err)
+		return nil, err
+	}
+
+	alert, err := s.repo.UpdateAlert(ctx, in)
+	if err != nil {
+		log.Printf("failed to update alert: %v", err)
+		return nil, err
+	}
+
+	alert.WebhookSecret = ""
+	return alert, nil
+}
+
+func (s *Service) DeleteAlert(ctx context.Context, userID, alertID int64) error {
+	if err := s.repo.DeleteAlert(ctx, userID, alertID); err != nil {
+		log.Printf("failed to delete alert: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *Service) GetAlertDeliveries(ctx context.Context, userID, alertID int64) ([]*models.AlertDelivery, error) {
+	if _, err := s.repo.GetAlert(ctx, userID, alertID); err != nil {
+		log.Printf("failed to get alert: %v", err)
+		return nil, err
+	}
+
+	deliveries, err := s.repo.GetAlertDeliveries(ctx, userID, alertID, maxAlertDeliveries)
+	if err != nil {
+		log.Printf("failed to get alert deliveries: %v", err)
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// checkAlerts evaluates the active alerts against a complete catalogue in the background
+// and delivers the ones that have just been triggered.
+func (s *Service) checkAlerts(catalogue *Catalogue) {
+	if !s.alertsEnabled {
+		return
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ctx, cancel := context.WithTimeout(context.Background(), alertsTimeout)
+		defer cancel()
+
+		alerts, err := s.repo.GetActiveAlerts(ctx, catalogue.Params.AppID, catalogue.Params.Currency)
+		if err != nil {
+			log.Printf("[ERROR] checkAlerts: %v\n", err)
+			return
+		}
+
+		matches, rearmed := evaluateAlerts(catalogue, alerts)
+
+		if len(rearmed) > 0 {
+			if err = s.repo.ResetAlerts(ctx, rearmed); err != nil {
+				log.Printf("[ERROR] checkAlerts: %v\n", err)
+			}
+		}
+
+		if len(matches) == 0 {
+			return
+		}
+
+		ids := make([]int64, len(matches))
+		for i, match := range matches {
+			ids[i] = match.alert.ID
+		}
+
+		triggered, err := s.repo.MarkAlertsTriggered(ctx, ids)
+		if err != nil {
+			log.Printf("[ERROR] checkAlerts: %v\n", err)
+			return
+		}
+
+		s.deliverAlerts(ctx, matches, triggered)
+	}()
+}
+
+// evaluateAlerts returns the alerts that are not triggered yet and whose condition holds,
+// and the ids of triggered alerts whose condition no longer holds and which can fire again.
+// An item missing from the catalogue has no price, so its alerts are not met.
+func evaluateAlerts(catalogue *Catalogue, alerts []*models.PriceAlert) ([]alertMatch, []int64) {
+	var (
+		matches []alertMatch
+		rearmed []int64
+	)
+
+	for _, alert := range alerts {
+		var price *float64
+		if item, ok := catalogue.Item(alert.MarketHashName); ok {
+			price = alert.Price(item)
+		}
+
+		met := alert.Met(price)
+		switch {
+		case met && !alert.Triggered:
+			matches = append(matches, alertMatch{alert: alert, price: *price})
+		case !met && alert.Triggered:
+			rearmed = append(rearmed, alert.ID)
+		}
+	}
+
+	return matches, rearmed
+}
+
+// deliverAlerts sends the webhooks of the matches whose ids are in triggered and logs every delivery.
+func (s *Service) deliverAlerts(ctx context.Context, matches []alertMatch, triggered []int64) {
+	deliver := make(map[int64]bool, len(triggered))
+	for _, id := range triggered {
+		deliver[id] = true
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, alertDeliveryConcurrency)
+
+	for _, match := range matches {
+		if !deliver[match.alert.ID] {
+			continue
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			s.deliverAlert(ctx, match)
+		}()
+	}
+
+	wg.Wait()
+}
+
+func (s *Service) deliverAlert(ctx context.Context, match alertMatch) {
+	alert := match.alert
+	event := models.AlertEvent{
+		EventID:        uuid.NewString(),
+		Type:           models.AlertEventTriggered,
+		AlertID:        alert.ID,
+		UserID:         alert.UserID,
+		AppID:          alert.AppID,
+		Currency:       alert.Currency,
+		MarketHashName: alert.MarketHashName,
+		Side:           alert.Side,
+		Condition:      alert.Condition,
+		Threshold:      alert.Threshold,
+		Price:          match.price,
+		TriggeredAt:    time.Now().UTC(),
+	}
+
+	result := s.webhookClient.Send(ctx, alert.WebhookURL, alert.WebhookSecret, event.EventID, event)
+
+	delivery := models.AlertDelivery{
+		AlertID:  alert.ID,
+		EventID:  event.EventID,
+		Price:    match.price,
+		Attempts: result.Attempts,
+		Success:  result.Err == nil,
+	}
+	if result.StatusCode != 0 {
+		delivery.StatusCode = &result.StatusCode
+	}
+	if result.Err != nil {
+		msg := result.Err.Error()
+		delivery.Error = &msg
+		log.Printf("[WARN] failed to deliver alert(%d) after %d attempts: %v\n", alert.ID, result.Attempts, result.Err)
+	}
+
+	if err := s.repo.InsertAlertDelivery(ctx, delivery); err != nil {
+		log.Printf("[ERROR] deliverAlert: %v\n", err)
+	}
+}
+
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+
+	return hex.EncodeToString(b), nil
+}
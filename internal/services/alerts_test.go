@@ -0,0 +1,60 @@
+package services
+
+import (
+	"testing"
+
+	"backend-test-golang/internal/models"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestEvaluateAlerts(t *testing.T) {
+	price := func(p float64) *float64 { return &p }
+
+	catalogue := newCatalogue(models.CatalogueParams{AppID: 730, Currency: "EUR"}, []*models.ItemResponse{
+		{MarketHashName: "AK-47 | Redline (Field-Tested)", MinPriceTradable: price(9), MinPriceNonTradable: price(12)},
+		{MarketHashName: "AWP | Asiimov (Field-Tested)", MinPriceTradable: nil, MinPriceNonTradable: price(50)},
+	})
+
+	alert := func(id int64, name string, side models.AlertSide, condition models.AlertCondition, threshold int64, triggered bool) *models.PriceAlert {
+		return &models.PriceAlert{
+			ID:             id,
+			MarketHashName: name,
+			Side:           side,
+			Condition:      condition,
+			Threshold:      decimal.NewFromInt(threshold),
+			Triggered:      triggered,
+		}
+	}
+
+	alerts := []*models.PriceAlert{
+		// met and not triggered yet: fires.
+		alert(1, "AK-47 | Redline (Field-Tested)", models.AlertSideTradable, models.AlertConditionBelow, 10, false),
+		// met but already fired: stays quiet.
+		alert(2, "AK-47 | Redline (Field-Tested)", models.AlertSideTradable, models.AlertConditionBelow, 10, true),
+		// watches the other side, which is above the threshold: re-armed.
+		alert(3, "AK-47 | Redline (Field-Tested)", models.AlertSideNonTradable, models.AlertConditionBelow, 10, true),
+		// no tradable listings: not met.
+		alert(4, "AWP | Asiimov (Field-Tested)", models.AlertSideTradable, models.AlertConditionBelow, 100, false),
+		alert(5, "AWP | Asiimov (Field-Tested)", models.AlertSideNonTradable, models.AlertConditionAbove, 40, false),
+		// the item left the catalogue: re-armed.
+		alert(6, "Unknown Item", models.AlertSideTradable, models.AlertConditionBelow, 10, true),
+	}
+
+	matches, rearmed := evaluateAlerts(catalogue, alerts)
+
+	var matched []int64
+	for _, match := range matches {
+		matched = append(matched, match.alert.ID)
+	}
+	if len(matched) != 2 || matched[0] != 1 || matched[1] != 5 {
+		t.Fatalf("got matched alerts %v, want [1 5]", matched)
+	}
+	if matches[0].price != 9 || matches[1].price != 50 {
+		t.Errorf("got prices %v and %v, want 9 and 50", matches[0].price, matches[1].price)
+	}
+
+	if len(rearmed) != 2 || rearmed[0] != 3 || rearmed[1] != 6 {
+		t.Errorf("got re-armed alerts %v, want [3 6]", rearmed)
+	}
+}
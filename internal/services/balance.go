@@ -5,6 +5,7 @@ import (
 	errs "backend-test-golang/pkg/errors"
 	"context"
 	"errors"
+	"fmt"
 	"log"
 )
 
@@ -15,12 +16,19 @@ func (s *Service) Withdraw(ctx context.Context, in models.WithdrawRequest) (*mod
 		return nil, err
 	}
 
+	want := models.Transaction{UserID: in.UserID, Type: models.TransactionTypeWithdrawal, Amount: in.Amount}
 	tx, err := s.repo.GetTransactionByIdempotencyKey(ctx, in.IdempotencyKey)
 	if err == nil {
-		return tx, nil
+		return idempotentTransaction(tx, want)
 	}
 
 	withdrawal, err := s.repo.Withdraw(ctx, in)
+	if errors.Is(err, errs.ErrIdempotencyKeyUsed) {
+		// A concurrent request with the same key won, answer with its transaction if it is the same withdraw.
+		if tx, getErr := s.repo.GetTransactionByIdempotencyKey(ctx, in.IdempotencyKey); getErr == nil {
+			return idempotentTransaction(tx, want)
+		}
+	}
 	if err != nil {
 		log.Printf("failed to withdraw: %v", err)
 		return nil, err
@@ -29,6 +37,130 @@ func (s *Service) Withdraw(ctx context.Context, in models.WithdrawRequest) (*mod
 	return withdrawal, nil
 }
 
+func (s *Service) Deposit(ctx context.Context, in models.DepositRequest) (*models.Transaction, error) {
+	if err := in.Validate(s.depositMaxAmount); err != nil {
+		err = errors.Join(errs.ErrValidationFailed, err)
+		log.Printf("validation error in deposit: %v", err)
+		return nil, err
+	}
+
+	want := models.Transaction{UserID: in.UserID, Type: models.TransactionTypeDeposit, Amount: in.Amount}
+	tx, err := s.repo.GetTransactionByIdempotencyKey(ctx, in.IdempotencyKey)
+	if err == nil {
+		return idempotentTransaction(tx, want)
+	}
+
+	deposit, err := s.repo.Deposit(ctx, in)
+	if errors.Is(err, errs.ErrIdempotencyKeyUsed) {
+		// A concurrent request with the same key won, answer with its transaction if it is the same deposit.
+		if tx, getErr := s.repo.GetTransactionByIdempotencyKey(ctx, in.IdempotencyKey); getErr == nil {
+			return idempotentTransaction(tx, want)
+		}
+	}
+	if err != nil {
+		log.Printf("failed to deposit: %v", err)
+		return nil, err
+	}
+
+	return deposit, nil
+}
+
+func (s *Service) Refund(ctx context.Context, in models.RefundRequest) (*models.Transaction, error) {
+	if err := in.Validate(); err != nil {
+		err = errors.Join(errs.ErrValidationFailed, err)
+		log.Printf("validation error in refund: %v", err)
+		return nil, err
+	}
+
+	// A zero amount refunds the rest of the transaction, so any stored amount matches it.
+	want := models.Transaction{Type: models.TransactionTypeRefund, ReferenceTransactionID: &in.TransactionID, Amount: in.Amount}
+	tx, err := s.repo.GetTransactionByIdempotencyKey(ctx, in.IdempotencyKey)
+	if err == nil {
+		return idempotentTransaction(tx, want)
+	}
+
+	refund, err := s.repo.Refund(ctx, in)
+	if errors.Is(err, errs.ErrIdempotencyKeyUsed) {
+		// A concurrent request with the same key won, answer with its transaction if it is the same refund.
+		if tx, getErr := s.repo.GetTransactionByIdempotencyKey(ctx, in.IdempotencyKey); getErr == nil {
+			return idempotentTransaction(tx, want)
+		}
+	}
+	if err != nil {
+		log.Printf("failed to refund: %v", err)
+		return nil, err
+	}
+
+	return refund, nil
+}
+
+func (s *Service) Transfer(ctx context.Context, in models.TransferRequest) (*models.Transfer, error) {
+	if err := in.Validate(); err != nil {
+		err = errors.Join(errs.ErrValidationFailed, err)
+		log.Printf("validation error in transfer: %v", err)
+		return nil, err
+	}
+
+	transfer, err := s.repo.GetTransferByIdempotencyKey(ctx, in.IdempotencyKey)
+	if err == nil {
+		return idempotentTransfer(transfer, in)
+	}
+	if !errors.Is(err, errs.ErrNotFound) {
+		log.Printf("failed to get transfer: %v", err)
+		return nil, err
+	}
+
+	transfer, err = s.repo.Transfer(ctx, in)
+	if errors.Is(err, errs.ErrIdempotencyKeyUsed) {
+		// A concurrent request with the same key won, answer with its transfer.
+		// The key may also belong to a transaction that is not a transfer, then the error stands.
+		if transfer, getErr := s.repo.GetTransferByIdempotencyKey(ctx, in.IdempotencyKey); getErr == nil {
+			return idempotentTransfer(transfer, in)
+		}
+	}
+	if err != nil {
+		log.Printf("failed to transfer: %v", err)
+		return nil, err
+	}
+
+	return transfer, nil
+}
+
+// idempotentTransaction returns the transaction already made with the request idempotency key,
+// unless the key was used by a different operation. Zero UserID and Amount and nil ReferenceTransactionID of want match any.
+func idempotentTransaction(tx *models.Transaction, want models.Transaction) (*models.Transaction, error) {
+	if tx.Type != want.Type {
+		return nil, fmt.Errorf("key of %s transaction(%d): %w", tx.Type, tx.ID, errs.ErrIdempotencyKeyUsed)
+	}
+
+	if want.UserID != 0 && tx.UserID != want.UserID {
+		return nil, fmt.Errorf("key of %s transaction(%d) of user(%d): %w", tx.Type, tx.ID, tx.UserID, errs.ErrIdempotencyKeyUsed)
+	}
+
+	if !want.Amount.IsZero() && !tx.Amount.Equal(want.Amount) {
+		return nil, fmt.Errorf("key of %s transaction(%d) of %s: %w", tx.Type, tx.ID, tx.Amount, errs.ErrIdempotencyKeyUsed)
+	}
+
+	if want.ReferenceTransactionID != nil &&
+		(tx.ReferenceTransactionID == nil || *tx.ReferenceTransactionID != *want.ReferenceTransactionID) {
+		return nil, fmt.Errorf("key of %s transaction(%d) of another transaction: %w", tx.Type, tx.ID, errs.ErrIdempotencyKeyUsed)
+	}
+
+	return tx, nil
+}
+
+// idempotentTransfer returns the transfer already made with the request idempotency key,
+// unless it moved another amount or between other users.
+func idempotentTransfer(transfer *models.Transfer, in models.TransferRequest) (*models.Transfer, error) {
+	debit, credit := transfer.Debit, transfer.Credit
+	if debit.UserID != in.FromUserID || credit.UserID != in.ToUserID || !debit.Amount.Equal(in.Amount) {
+		return nil, fmt.Errorf("key of transfer(%d) of %s from user(%d) to user(%d): %w",
+			debit.ID, debit.Amount, debit.UserID, credit.UserID, errs.ErrIdempotencyKeyUsed)
+	}
+
+	return transfer, nil
+}
+
 func (s *Service) GetBalance(ctx context.Context, userID int64) (models.Balance, error) {
 	user, err := s.repo.GetUser(ctx, userID)
 	if err != nil {
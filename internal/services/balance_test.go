@@ -0,0 +1,186 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"backend-test-golang/internal/config"
+	"backend-test-golang/internal/models"
+	"backend-test-golang/internal/repository"
+	"backend-test-golang/pkg/database"
+	errs "backend-test-golang/pkg/errors"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// newTestServiceWithDB builds a service on the scratch database in TEST_DB_URL with the migrations applied,
+// see the repository tests. It is skipped without it.
+func newTestServiceWithDB(t *testing.T) (*Service, *database.DB) {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DB_URL")
+	if dsn == "" {
+		t.Skip("TEST_DB_URL is not set")
+	}
+
+	db, err := database.Connect(dsn)
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrations, err := filepath.Glob("../../migrations/0*.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range append([]string{"../../migrations/init.sql"}, migrations...) {
+		script, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = db.Exec(string(script)); err != nil {
+			t.Fatalf("failed to apply %s: %v", file, err)
+		}
+	}
+
+	return New(&config.Config{}, nil, nil, repository.New(db)), db
+}
+
+func createTestUser(t *testing.T, db *database.DB, balance int64) int64 {
+	t.Helper()
+
+	var id int64
+	if err := db.QueryRow("insert into users (balance) values ($1) returning id", balance).Scan(&id); err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	return id
+}
+
+func TestService_Transfer_ReplayedKey(t *testing.T) {
+	svc, db := newTestServiceWithDB(t)
+	ctx := context.Background()
+
+	from := createTestUser(t, db, 100)
+	to := createTestUser(t, db, 0)
+	other := createTestUser(t, db, 0)
+
+	req := models.TransferRequest{IdempotencyKey: uuid.NewString(), FromUserID: from, ToUserID: to, Amount: decimal.NewFromInt(10)}
+	transfer, err := svc.Transfer(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("same request returns the transfer", func(t *testing.T) {
+		got, err := svc.Transfer(ctx, req)
+		if err != nil || got.Debit.ID != transfer.Debit.ID {
+			t.Errorf("got %+v, %v, want transfer with debit %d", got, err, transfer.Debit.ID)
+		}
+	})
+
+	t.Run("different request is rejected", func(t *testing.T) {
+		for _, modified := range []models.TransferRequest{
+			{IdempotencyKey: req.IdempotencyKey, FromUserID: from, ToUserID: other, Amount: req.Amount},
+			{IdempotencyKey: req.IdempotencyKey, FromUserID: from, ToUserID: to, Amount: decimal.NewFromInt(11)},
+		} {
+			if _, err := svc.Transfer(ctx, modified); !errors.Is(err, errs.ErrIdempotencyKeyUsed) {
+				t.Errorf("got error %v for %+v, want %v", err, modified, errs.ErrIdempotencyKeyUsed)
+			}
+		}
+	})
+
+	t.Run("key of a withdrawal is rejected", func(t *testing.T) {
+		key := uuid.NewString()
+		if _, err := svc.Withdraw(ctx, models.WithdrawRequest{IdempotencyKey: key, UserID: from, Amount: decimal.NewFromInt(1)}); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := svc.Transfer(ctx, models.TransferRequest{IdempotencyKey: key, FromUserID: from, ToUserID: to, Amount: decimal.NewFromInt(1)})
+		if !errors.Is(err, errs.ErrIdempotencyKeyUsed) {
+			t.Errorf("got error %v, want %v", err, errs.ErrIdempotencyKeyUsed)
+		}
+	})
+}
+
+func TestIdempotentTransfer(t *testing.T) {
+	transfer := &models.Transfer{
+		Debit:  &models.Transaction{ID: 1, UserID: 7, Type: models.TransactionTypeTransferOut, Amount: decimal.NewFromInt(10)},
+		Credit: &models.Transaction{ID: 2, UserID: 8, Type: models.TransactionTypeTransferIn, Amount: decimal.NewFromInt(10)},
+	}
+
+	tests := []struct {
+		name    string
+		req     models.TransferRequest
+		wantErr bool
+	}{
+		{name: "same request", req: models.TransferRequest{FromUserID: 7, ToUserID: 8, Amount: decimal.RequireFromString("10.00")}},
+		{name: "other sender", req: models.TransferRequest{FromUserID: 9, ToUserID: 8, Amount: decimal.NewFromInt(10)}, wantErr: true},
+		{name: "other recipient", req: models.TransferRequest{FromUserID: 7, ToUserID: 9, Amount: decimal.NewFromInt(10)}, wantErr: true},
+		{name: "other amount", req: models.TransferRequest{FromUserID: 7, ToUserID: 8, Amount: decimal.NewFromInt(11)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := idempotentTransfer(transfer, tt.req)
+			if tt.wantErr {
+				if !errors.Is(err, errs.ErrIdempotencyKeyUsed) {
+					t.Errorf("got error %v, want %v", err, errs.ErrIdempotencyKeyUsed)
+				}
+				return
+			}
+
+			if err != nil || got != transfer {
+				t.Errorf("got %v, %v, want the original transfer", got, err)
+			}
+		})
+	}
+}
+
+func TestIdempotentTransaction(t *testing.T) {
+	deposit := &models.Transaction{ID: 1, UserID: 7, Type: models.TransactionTypeDeposit, Amount: decimal.NewFromInt(10)}
+
+	tests := []struct {
+		name    string
+		want    models.Transaction
+		wantErr bool
+	}{
+		{name: "same request", want: models.Transaction{UserID: 7, Type: models.TransactionTypeDeposit, Amount: decimal.NewFromInt(10)}},
+		{name: "type only", want: models.Transaction{Type: models.TransactionTypeDeposit}},
+		{name: "other type", want: models.Transaction{UserID: 7, Type: models.TransactionTypeWithdrawal, Amount: decimal.NewFromInt(10)}, wantErr: true},
+		{name: "other user", want: models.Transaction{UserID: 8, Type: models.TransactionTypeDeposit, Amount: decimal.NewFromInt(10)}, wantErr: true},
+		{name: "other amount", want: models.Transaction{UserID: 7, Type: models.TransactionTypeDeposit, Amount: decimal.NewFromInt(11)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := idempotentTransaction(deposit, tt.want)
+			if tt.wantErr {
+				if !errors.Is(err, errs.ErrIdempotencyKeyUsed) {
+					t.Errorf("got error %v, want %v", err, errs.ErrIdempotencyKeyUsed)
+				}
+				return
+			}
+
+			if err != nil || got != deposit {
+				t.Errorf("got %v, %v, want the original deposit", got, err)
+			}
+		})
+	}
+}
+
+func TestIdempotentTransaction_Refund(t *testing.T) {
+	purchaseID, otherID := int64(3), int64(4)
+	refund := &models.Transaction{ID: 5, Type: models.TransactionTypeRefund, ReferenceTransactionID: &purchaseID, Amount: decimal.NewFromInt(4)}
+
+	if got, err := idempotentTransaction(refund, models.Transaction{Type: models.TransactionTypeRefund, ReferenceTransactionID: &purchaseID}); err != nil || got != refund {
+		t.Errorf("got %v, %v, want the original refund", got, err)
+	}
+
+	_, err := idempotentTransaction(refund, models.Transaction{Type: models.TransactionTypeRefund, ReferenceTransactionID: &otherID})
+	if !errors.Is(err, errs.ErrIdempotencyKeyUsed) {
+		t.Errorf("got error %v, want %v", err, errs.ErrIdempotencyKeyUsed)
+	}
+}
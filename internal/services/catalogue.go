@@ -0,0 +1,117 @@
+package services
+
+import (
+	"backend-test-golang/internal/models"
+	"cmp"
+	"encoding/binary"
+	"hash"
+	"hash/fnv"
+	"math"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// Catalogue is a merged Skinport catalogue snapshot with an index by market_hash_name.
+// A cached catalogue is shared between requests and must be treated as read-only.
+type Catalogue struct {
+	Params      models.CatalogueParams
+	Version     int64
+	Items       []*models.ItemResponse
+	ContentHash string
+	FetchedAt   time.Time
+	// ModifiedAt is when the content last changed, it stays the same over refreshes that fetch the same catalogue.
+	ModifiedAt time.Time
+	ExpiresAt  time.Time
+	Stale      bool
+	Partial    bool
+	Warning    string
+	byName     map[string]*models.ItemResponse
+}
+
+func newCatalogue(params models.CatalogueParams, items []*models.ItemResponse) *Catalogue {
+	byName := make(map[string]*models.ItemResponse, len(items))
+	for _, item := range items {
+		byName[item.MarketHashName] = item
+	}
+
+	return &Catalogue{
+		Params:      params,
+		Items:       items,
+		ContentHash: catalogueHash(items),
+		byName:      byName,
+	}
+}
+
+// catalogueHash is a hash of everything items responses are built from, independent of the item order.
+func catalogueHash(items []*models.ItemResponse) string {
+	sorted := slices.Clone(items)
+	slices.SortFunc(sorted, func(a, b *models.ItemResponse) int {
+		return cmp.Compare(a.MarketHashName, b.MarketHashName)
+	})
+
+	h := fnv.New64a()
+	for _, item := range sorted {
+		writeString(h, item.MarketHashName)
+		writeString(h, item.Currency)
+		writeString(h, string(item.Availability))
+		writePrice(h, item.SuggestedPrice)
+		writePrice(h, item.MinPriceTradable)
+		writePrice(h, item.MinPriceNonTradable)
+		writeStats(h, item.Tradable)
+		writeStats(h, item.NonTradable)
+	}
+
+	return strconv.FormatUint(h.Sum64(), 16)
+}
+
+func writeString(h hash.Hash64, s string) {
+	_, _ = h.Write([]byte(s))
+	_, _ = h.Write([]byte{0})
+}
+
+func writePrice(h hash.Hash64, price *float64) {
+	if price == nil {
+		_, _ = h.Write([]byte{0})
+		return
+	}
+	_, _ = h.Write(binary.LittleEndian.AppendUint64([]byte{1}, math.Float64bits(*price)))
+}
+
+func writeStats(h hash.Hash64, stats *models.ItemStats) {
+	if stats == nil {
+		_, _ = h.Write([]byte{0})
+		return
+	}
+
+	_, _ = h.Write([]byte{1})
+	writePrice(h, stats.MinPrice)
+	writePrice(h, stats.MaxPrice)
+	writePrice(h, stats.MeanPrice)
+	writePrice(h, stats.MedianPrice)
+	_, _ = h.Write(binary.LittleEndian.AppendUint64(nil, uint64(stats.Quantity)))
+	writeString(h, stats.ItemPage)
+	writeString(h, stats.MarketPage)
+	_, _ = h.Write(binary.LittleEndian.AppendUint64(nil, uint64(stats.CreatedAt.Unix())))
+	_, _ = h.Write(binary.LittleEndian.AppendUint64(nil, uint64(stats.UpdatedAt.Unix())))
+}
+
+// Item looks an item up by its exact market_hash_name.
+func (c *Catalogue) Item(marketHashName string) (*models.ItemResponse, bool) {
+	item, ok := c.byName[marketHashName]
+	return item, ok
+}
+
+func (c *Catalogue) Snapshot() models.CatalogueSnapshot {
+	return models.CatalogueSnapshot{
+		Params:      c.Params,
+		Version:     c.Version,
+		ContentHash: c.ContentHash,
+		FetchedAt:   c.FetchedAt,
+		ModifiedAt:  c.ModifiedAt,
+		ExpiresAt:   c.ExpiresAt,
+		Stale:       c.Stale,
+		Partial:     c.Partial,
+		Warning:     c.Warning,
+	}
+}
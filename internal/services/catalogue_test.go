@@ -0,0 +1,75 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"backend-test-golang/internal/models"
+)
+
+func TestCatalogue_Item(t *testing.T) {
+	catalogue := newCatalogue(models.CatalogueParams{AppID: 730, Currency: "EUR"}, testCatalogue())
+
+	item, ok := catalogue.Item("AK-47 | Redline (Field-Tested)")
+	if !ok {
+		t.Fatal("expected item to be found")
+	}
+	if item.MarketHashName != "AK-47 | Redline (Field-Tested)" {
+		t.Errorf("got item %q", item.MarketHashName)
+	}
+
+	if _, ok = catalogue.Item("ak-47 | redline (field-tested)"); ok {
+		t.Error("lookup must be exact")
+	}
+
+	if _, ok = catalogue.Item("Unknown Item"); ok {
+		t.Error("unknown item must not be found")
+	}
+}
+
+func TestCatalogueHash(t *testing.T) {
+	items := testCatalogue()
+	reversed := make([]*models.ItemResponse, len(items))
+	for i, item := range items {
+		reversed[len(items)-1-i] = item
+	}
+
+	if catalogueHash(items) != catalogueHash(reversed) {
+		t.Error("hash must not depend on the item order")
+	}
+
+	changed := *items[0]
+	price := 123.45
+	changed.MinPriceTradable = &price
+	withChange := append([]*models.ItemResponse{&changed}, items[1:]...)
+
+	if catalogueHash(items) == catalogueHash(withChange) {
+		t.Error("hash must change with a price")
+	}
+}
+
+func TestChangeFeed_ModifiedAt(t *testing.T) {
+	feed := newChangeFeed(10)
+	first := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	catalogue := func(modifiedAt time.Time, items []*models.ItemResponse) *Catalogue {
+		c := newCatalogue(models.CatalogueParams{AppID: 730, Currency: "EUR"}, items)
+		c.ModifiedAt = modifiedAt
+		return c
+	}
+
+	feed.record(catalogue(first, testCatalogue()))
+
+	same := catalogue(first.Add(5*time.Minute), testCatalogue())
+	feed.record(same)
+	if !same.ModifiedAt.Equal(first) {
+		t.Errorf("got ModifiedAt %v for unchanged content, want %v", same.ModifiedAt, first)
+	}
+
+	changedAt := first.Add(10 * time.Minute)
+	changed := catalogue(changedAt, testCatalogue()[1:])
+	feed.record(changed)
+	if !changed.ModifiedAt.Equal(changedAt) {
+		t.Errorf("got ModifiedAt %v for changed content, want %v", changed.ModifiedAt, changedAt)
+	}
+}
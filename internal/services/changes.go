@@ -0,0 +1,211 @@
+package services
+
+import (
+	"backend-test-golang/internal/models"
+	errs "backend-test-golang/pkg/errors"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+)
+
+// changeFeed numbers complete catalogues per app/currency and keeps the diffs of the last versions,
+// so that clients can pull what changed since the version they have instead of the whole catalogue.
+// Versions start at 1 and only grow while the server runs; a catalogue without changes keeps its version.
+type changeFeed struct {
+	mu       sync.Mutex
+	capacity int
+	feeds    map[models.CatalogueParams]*catalogueFeed
+}
+
+type catalogueFeed struct {
+	version int64
+	last    *Catalogue
+	// versions holds the diffs of the last capacity versions, oldest first.
+	versions [][]models.ItemChange
+}
+
+func newChangeFeed(capacity int) *changeFeed {
+	return &changeFeed{
+		capacity: max(capacity, 0),
+		feeds:    make(map[models.CatalogueParams]*catalogueFeed),
+	}
+}
+
+// record diffs a complete catalogue against the previous one and returns its version and the changes.
+// A catalogue with the same content as the previous one keeps its ModifiedAt.
+func (f *changeFeed) record(catalogue *Catalogue) (int64, []models.ItemChange) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	feed, ok := f.feeds[catalogue.Params]
+	if !ok {
+		f.feeds[catalogue.Params] = &catalogueFeed{version: 1, last: catalogue}
+		return 1, nil
+	}
+
+	if feed.last.ContentHash == catalogue.ContentHash {
+		catalogue.ModifiedAt = feed.last.ModifiedAt
+	}
+
+	changes := diffCatalogues(feed.last, catalogue)
+	feed.last = catalogue
+	if len(changes) == 0 {
+		return feed.version, nil
+	}
+
+	feed.version++
+	for i := range changes {
+		changes[i].Version = feed.version
+	}
+
+	feed.versions = append(feed.versions, changes)
+	if len(feed.versions) > f.capacity {
+		feed.versions = slices.Delete(feed.versions, 0, len(feed.versions)-f.capacity)
+	}
+
+	return feed.version, changes
+}
+
+// version returns the current version of a catalogue, 0 when none was recorded yet.
+func (f *changeFeed) version(params models.CatalogueParams) int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if feed, ok := f.feeds[params]; ok {
+		return feed.version
+	}
+	return 0
+}
+
+// since returns the changes after version since. It fails with ErrChangesExpired when some of them are
+// no longer kept or since is newer than the current version, e.g. after a restart.
+func (f *changeFeed) since(params models.CatalogueParams, since int64) (*models.ItemChanges, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	feed, ok := f.feeds[params]
+	if !ok {
+		return nil, errs.ErrChangesExpired
+	}
+
+	oldest := feed.version - int64(len(feed.versions))
+	if since < oldest || since > feed.version {
+		return nil, fmt.Errorf("%w: since %d, available from %d to %d", errs.ErrChangesExpired, since, oldest, feed.version)
+	}
+
+	result := &models.ItemChanges{
+		AppID:    params.AppID,
+		Currency: params.Currency,
+		Since:    since,
+		Version:  feed.version,
+		Changes:  make([]models.ItemChange, 0),
+	}
+	for _, changes := range feed.versions[since-oldest:] {
+		result.Changes = append(result.Changes, changes...)
+	}
+
+	return result, nil
+}
+
+// GetItemChanges returns what changed in the catalogue after version since. The first call for
+// an app/currency loads the catalogue, which becomes version 1.
+func (s *Service) GetItemChanges(ctx context.Context, params models.CatalogueParams, since int64) (*models.ItemChanges, error) {
+	params, err := s.catalogueParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	if since < 0 {
+		err = errors.Join(errs.ErrValidationFailed, errors.New("since must not be negative"))
+		log.Printf("validation error in get item changes: %v", err)
+		return nil, err
+	}
+
+	if s.changes.version(params) == 0 {
+		if _, err = s.GetItems(ctx, params); err != nil {
+			return nil, err
+		}
+	}
+
+	changes, err := s.changes.since(params, since)
+	if err != nil {
+		log.Printf("failed to get item changes: %v", err)
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+// diffCatalogues lists added and removed items, min price changes of both sides and availability changes,
+// ordered by market_hash_name.
+func diffCatalogues(prev, next *Catalogue) []models.ItemChange {
+	var changes []models.ItemChange
+
+	for _, item := range next.Items {
+		old, ok := prev.Item(item.MarketHashName)
+		if !ok {
+			changes = append(changes, models.ItemChange{
+				Type:           models.ItemChangeAdded,
+				MarketHashName: item.MarketHashName,
+				Item:           item,
+			})
+			continue
+		}
+
+		if change, ok := priceChange(item.MarketHashName, models.SideTradable, old.MinPriceTradable, item.MinPriceTradable); ok {
+			changes = append(changes, change)
+		}
+		if change, ok := priceChange(item.MarketHashName, models.SideNonTradable, old.MinPriceNonTradable, item.MinPriceNonTradable); ok {
+			changes = append(changes, change)
+		}
+
+		if old.Availability != item.Availability {
+			changes = append(changes, models.ItemChange{
+				Type:            models.ItemChangeAvailability,
+				MarketHashName:  item.MarketHashName,
+				OldAvailability: old.Availability,
+				NewAvailability: item.Availability,
+			})
+		}
+	}
+
+	for _, item := range prev.Items {
+		if _, ok := next.Item(item.MarketHashName); !ok {
+			changes = append(changes, models.ItemChange{
+				Type:           models.ItemChangeRemoved,
+				MarketHashName: item.MarketHashName,
+			})
+		}
+	}
+
+	slices.SortStableFunc(changes, func(a, b models.ItemChange) int {
+		return cmp.Compare(a.MarketHashName, b.MarketHashName)
+	})
+
+	return changes
+}
+
+// priceChange compares min prices of one side. A price that appears or disappears is
+// an availability change rather than a price change.
+func priceChange(marketHashName, side string, oldPrice, newPrice *float64) (models.ItemChange, bool) {
+	if oldPrice == nil || newPrice == nil || *oldPrice == *newPrice {
+		return models.ItemChange{}, false
+	}
+
+	changeType := models.ItemChangePriceUp
+	if *newPrice < *oldPrice {
+		changeType = models.ItemChangePriceDown
+	}
+
+	return models.ItemChange{
+		Type:           changeType,
+		MarketHashName: marketHashName,
+		Side:           side,
+		OldPrice:       oldPrice,
+		NewPrice:       newPrice,
+	}, true
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"backend-test-golang/internal/models"
+	errs "backend-test-golang/pkg/errors"
+)
+
+func testChangesCatalogue(items ...*models.ItemResponse) *Catalogue {
+	return newCatalogue(models.CatalogueParams{AppID: 730, Currency: "EUR"}, items)
+}
+
+func testChangesItem(name string, tradable, nonTradable *float64) *models.ItemResponse {
+	item := &models.ItemResponse{MarketHashName: name, Currency: "EUR", MinPriceTradable: tradable, MinPriceNonTradable: nonTradable}
+	if tradable != nil {
+		item.Tradable = &models.ItemStats{MinPrice: tradable, Quantity: 1}
+	}
+	if nonTradable != nil {
+		item.NonTradable = &models.ItemStats{MinPrice: nonTradable, Quantity: 1}
+	}
+	item.Availability = availability(item)
+	return item
+}
+
+func TestDiffCatalogues(t *testing.T) {
+	price := func(p float64) *float64 { return &p }
+
+	prev := testChangesCatalogue(
+		testChangesItem("AK-47 | Redline (Field-Tested)", price(10), price(12)),
+		testChangesItem("AWP | Asiimov (Field-Tested)", price(50), nil),
+		testChangesItem("Glock-18 | Fade (Factory New)", price(300), nil),
+		testChangesItem("M4A4 | Howl (Field-Tested)", price(2000), nil),
+	)
+	next := testChangesCatalogue(
+		testChangesItem("AK-47 | Redline (Field-Tested)", price(11), price(9)),
+		testChangesItem("AWP | Asiimov (Field-Tested)", price(50), price(45)),
+		testChangesItem("Glock-18 | Fade (Factory New)", price(300), nil),
+		testChangesItem("USP-S | Kill Confirmed (Minimal Wear)", price(40), nil),
+	)
+
+	got := diffCatalogues(prev, next)
+
+	want := []models.ItemChange{
+		{Type: models.ItemChangePriceUp, MarketHashName: "AK-47 | Redline (Field-Tested)", Side: models.SideTradable},
+		{Type: models.ItemChangePriceDown, MarketHashName: "AK-47 | Redline (Field-Tested)", Side: models.SideNonTradable},
+		{Type: models.ItemChangeAvailability, MarketHashName: "AWP | Asiimov (Field-Tested)"},
+		{Type: models.ItemChangeRemoved, MarketHashName: "M4A4 | Howl (Field-Tested)"},
+		{Type: models.ItemChangeAdded, MarketHashName: "USP-S | Kill Confirmed (Minimal Wear)"},
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("got %d changes %+v, want %d", len(got), got, len(want))
+	}
+	for i := range want {
+		if got[i].Type != want[i].Type || got[i].MarketHashName != want[i].MarketHashName || got[i].Side != want[i].Side {
+			t.Errorf("change %d: got %s %q %s, want %s %q %s", i,
+				got[i].Type, got[i].MarketHashName, got[i].Side, want[i].Type, want[i].MarketHashName, want[i].Side)
+		}
+	}
+
+	if *got[0].OldPrice != 10 || *got[0].NewPrice != 11 {
+		t.Errorf("got price change %v -> %v, want 10 -> 11", *got[0].OldPrice, *got[0].NewPrice)
+	}
+	if got[2].OldAvailability != models.AvailabilityTradableOnly || got[2].NewAvailability != models.AvailabilityBoth {
+		t.Errorf("got availability change %s -> %s", got[2].OldAvailability, got[2].NewAvailability)
+	}
+	if got[4].Item == nil || got[4].Item.MarketHashName != "USP-S | Kill Confirmed (Minimal Wear)" {
+		t.Errorf("added change must carry the item, got %+v", got[4].Item)
+	}
+
+	if changes := diffCatalogues(next, next); len(changes) != 0 {
+		t.Errorf("got changes %+v between equal catalogues", changes)
+	}
+}
+
+func TestChangeFeed(t *testing.T) {
+	price := func(p float64) *float64 { return &p }
+	params := models.CatalogueParams{AppID: 730, Currency: "EUR"}
+	catalogue := func(p float64) *Catalogue {
+		return testChangesCatalogue(testChangesItem("AK-47 | Redline (Field-Tested)", price(p), nil))
+	}
+
+	feed := newChangeFeed(2)
+
+	if _, err := feed.since(params, 0); !errors.Is(err, errs.ErrChangesExpired) {
+		t.Errorf("got error %v for an unknown catalogue, want %v", err, errs.ErrChangesExpired)
+	}
+
+	var versions []int64
+	for _, p := range []float64{10, 10, 11, 12, 9} { // the second catalogue has no changes and keeps the version
+		version, _ := feed.record(catalogue(p))
+		versions = append(versions, version)
+	}
+	if want := []int64{1, 1, 2, 3, 4}; !equalVersions(versions, want) {
+		t.Fatalf("got versions %v, want %v", versions, want)
+	}
+
+	t.Run("changes after a kept version", func(t *testing.T) {
+		changes, err := feed.since(params, 2)
+		if err != nil {
+			t.Fatalf("got unexpected error: %v", err)
+		}
+		if changes.Version != 4 || len(changes.Changes) != 2 {
+			t.Fatalf("got %+v, want 2 changes up to version 4", changes)
+		}
+		if changes.Changes[0].Version != 3 || changes.Changes[0].Type != models.ItemChangePriceUp ||
+			changes.Changes[1].Version != 4 || changes.Changes[1].Type != models.ItemChangePriceDown {
+			t.Errorf("got changes %+v", changes.Changes)
+		}
+	})
+
+	t.Run("up to date", func(t *testing.T) {
+		changes, err := feed.since(params, 4)
+		if err != nil || len(changes.Changes) != 0 {
+			t.Errorf("got %+v, %v, want no changes", changes, err)
+		}
+	})
+
+	t.Run("version is no longer kept", func(t *testing.T) {
+		if _, err := feed.since(params, 1); !errors.Is(err, errs.ErrChangesExpired) {
+			t.Errorf("got error %v, want %v", err, errs.ErrChangesExpired)
+		}
+	})
+
+	t.Run("version from the future", func(t *testing.T) {
+		if _, err := feed.since(params, 5); !errors.Is(err, errs.ErrChangesExpired) {
+			t.Errorf("got error %v, want %v", err, errs.ErrChangesExpired)
+		}
+	})
+
+	if feed.version(models.CatalogueParams{AppID: 570, Currency: "EUR"}) != 0 {
+		t.Error("versions must be kept per catalogue")
+	}
+}
+
+func equalVersions(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
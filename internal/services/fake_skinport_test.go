@@ -0,0 +1,134 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"backend-test-golang/internal/config"
+	"backend-test-golang/pkg/cache"
+	"backend-test-golang/pkg/skinport"
+
+	"github.com/andybalholm/brotli"
+)
+
+// fakeSkinport is a local stand-in for the Skinport API serving brotli encoded /items responses.
+type fakeSkinport struct {
+	server      *httptest.Server
+	tradable    fakeSkinportSide
+	nonTradable fakeSkinportSide
+	calls       atomic.Int32
+	cancelled   atomic.Int32
+}
+
+// fakeSkinportSide configures the responses for one value of the tradable query param.
+type fakeSkinportSide struct {
+	items       []skinport.Item
+	status      atomic.Int32
+	delay       atomic.Int64
+	truncate    atomic.Bool                      // cut the JSON body before the end of the last item
+	waitStarted atomic.Pointer[fakeSkinportSide] // answer only once a request for that side has started
+	started     chan struct{}                    // closed when the first request for this side arrives
+	startOnce   sync.Once
+}
+
+func newFakeSkinport(t *testing.T, tradable, nonTradable []skinport.Item) *fakeSkinport {
+	t.Helper()
+
+	f := &fakeSkinport{}
+	f.tradable.items = tradable
+	f.nonTradable.items = nonTradable
+	f.tradable.started = make(chan struct{})
+	f.nonTradable.started = make(chan struct{})
+	f.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.calls.Add(1)
+
+		side := &f.nonTradable
+		if r.URL.Query().Get("tradable") == "true" {
+			side = &f.tradable
+		}
+		side.startOnce.Do(func() { close(side.started) })
+
+		if other := side.waitStarted.Load(); other != nil {
+			select {
+			case <-other.started:
+			case <-r.Context().Done():
+				return
+			}
+		}
+
+		if delay := time.Duration(side.delay.Load()); delay > 0 {
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+				f.cancelled.Add(1)
+				return
+			}
+		}
+
+		if status := int(side.status.Load()); status != 0 {
+			w.WriteHeader(status)
+			return
+		}
+
+		body, _ := json.Marshal(side.items)
+		if side.truncate.Load() {
+			body = body[:len(body)-10]
+		}
+
+		w.Header().Set("Content-Encoding", "br")
+		bw := brotli.NewWriter(w)
+		defer bw.Close()
+		bw.Write(body)
+	}))
+	t.Cleanup(f.server.Close)
+
+	return f
+}
+
+// failWith makes the fake answer every request with the given status, 0 restores normal responses.
+func (f *fakeSkinport) failWith(status int) {
+	f.tradable.status.Store(int32(status))
+	f.nonTradable.status.Store(int32(status))
+}
+
+// newTestService builds a service talking to the fake Skinport API with the default CS2/EUR catalogue.
+func newTestService(t *testing.T, f *fakeSkinport, cacheTTL int) *Service {
+	t.Helper()
+
+	return newTestServiceWithConfig(t, f, &config.Config{CacheTTLSeconds: cacheTTL})
+}
+
+func newTestServiceWithConfig(t *testing.T, f *fakeSkinport, conf *config.Config) *Service {
+	t.Helper()
+
+	client, err := skinport.NewClient("", "", f.server.URL)
+	if err != nil {
+		t.Fatalf("failed to create skinport client: %v", err)
+	}
+
+	mcache := cache.New(60)
+	t.Cleanup(func() { mcache.Close() })
+
+	conf.SkinportAppID = skinport.DefaultAppID
+	conf.SkinportCurrency = skinport.DefaultCurrency
+
+	return New(conf, mcache, client, nil)
+}
+
+func waitFor(t *testing.T, timeout time.Duration, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("condition was not met in time")
+}
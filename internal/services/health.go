@@ -0,0 +1,51 @@
+package services
+
+import (
+	"backend-test-golang/internal/models"
+	"backend-test-golang/pkg/circuitbreaker"
+	"backend-test-golang/pkg/skinport/salefeed"
+)
+
+// SetSaleFeed makes the live sale feed part of the health report and forwards its listings to the price streams.
+// The feed must be run, Close waits until it stops.
+func (s *Service) SetSaleFeed(feed *salefeed.Client) {
+	s.saleFeed = feed
+
+	sub := feed.Subscribe(saleFeedBuffer)
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.forwardListings(sub)
+	}()
+}
+
+// Health reports the state of the Skinport dependency. The service is degraded while
+// the circuit breaker is not closed: catalogues are served from the stale cache, if any.
+// A disconnected sale feed does not degrade the service, the catalogue does not depend on it.
+func (s *Service) Health() models.Health {
+	state := s.skinportClient.CircuitState()
+
+	health := models.Health{
+		Status: models.HealthStatusOK,
+		Skinport: models.SkinportHealth{
+			Circuit:           state.String(),
+			RemainingRequests: s.skinportClient.RemainingRequests(),
+		},
+	}
+	if state != circuitbreaker.StateClosed {
+		health.Status = models.HealthStatusDegraded
+	}
+
+	if s.saleFeed != nil {
+		feed := &models.SaleFeedHealth{
+			Connected:     s.saleFeed.Connected(),
+			DroppedEvents: s.saleFeed.Dropped(),
+		}
+		if lastEventAt := s.saleFeed.LastEventAt(); !lastEventAt.IsZero() {
+			feed.LastEventAt = &lastEventAt
+		}
+		health.Skinport.SaleFeed = feed
+	}
+
+	return health
+}
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"backend-test-golang/internal/models"
+	errs "backend-test-golang/pkg/errors"
+	"context"
+	"errors"
+	"log"
+	"time"
+)
+
+// GetPriceHistory returns the min/avg/max prices of an item per hour, day or week from the saved snapshots.
+// Without from and to the last 30 days up to now are returned.
+func (s *Service) GetPriceHistory(ctx context.Context, q models.PriceHistoryQuery) (*models.PriceHistory, error) {
+	params, err := s.catalogueParams(q.CatalogueParams)
+	if err != nil {
+		return nil, err
+	}
+	q.CatalogueParams = params
+
+	if q.Granularity == "" {
+		q.Granularity = models.PriceHistoryDay
+	}
+	if q.To.IsZero() {
+		q.To = time.Now()
+	}
+	if q.From.IsZero() {
+		q.From = q.To.Add(-models.DefaultPriceHistoryRange)
+	}
+
+	if err = q.Validate(); err != nil {
+		err = errors.Join(errs.ErrValidationFailed, err)
+		log.Printf("validation error in get price history: %v", err)
+		return nil, err
+	}
+
+	points, err := s.repo.GetPriceHistory(ctx, q)
+	if err != nil {
+		log.Printf("[ERROR] GetPriceHistory: %v\n", err)
+		return nil, err
+	}
+
+	return &models.PriceHistory{
+		MarketHashName: q.MarketHashName,
+		AppID:          q.AppID,
+		Currency:       q.Currency,
+		Granularity:    q.Granularity,
+		From:           q.From.UTC(),
+		To:             q.To.UTC(),
+		Points:         points,
+	}, nil
+}
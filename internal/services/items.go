@@ -8,88 +8,347 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"strings"
+	"sync"
+	"time"
 )
 
 const (
 	skinportItemsCacheKey = "skinport:items"
+	staleCacheKeySuffix   = ":stale"
+
+	// catalogueSizeHint is about the number of distinct CS2 items on Skinport.
+	catalogueSizeHint = 20000
 )
 
-func (s *Service) GetItems(ctx context.Context) ([]*models.ItemResponse, error) {
-	cachedItems, err := s.getCachedItems()
-	if err == nil {
-		return cachedItems, nil
+func (s *Service) GetItems(ctx context.Context, params models.CatalogueParams) (*Catalogue, error) {
+	params, err := s.catalogueParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheKey := catalogueCacheKey(skinportItemsCacheKey, params)
+
+	// Concurrent cache misses share one fetch, so a burst of requests spends the rate limit only once.
+	entry, err := s.cache.GetOrLoad(ctx, cacheKey, s.defaultCacheTTL, func(ctx context.Context) (any, error) {
+		return s.fetchItems(ctx, params)
+	})
+	if err != nil {
+		staleCatalogue, staleErr := getCached[*Catalogue](s, cacheKey+staleCacheKeySuffix)
+		if staleErr != nil {
+			return nil, err
+		}
+
+		log.Printf("[WARN] GetItems: serving stale catalogue fetched at %s: %v\n", staleCatalogue.FetchedAt.Format(time.RFC3339), err)
+
+		stale := *staleCatalogue
+		stale.Stale = true
+		return &stale, nil
 	}
 
-	if !errors.Is(err, errs.ErrNotFound) {
-		log.Printf("[WARN] GetItems: failed to get cached items: %v\n", err)
+	catalogue, ok := entry.(*Catalogue)
+	if !ok {
+		return nil, fmt.Errorf("failed to get items: %w", errs.ErrInvalidCacheEntry)
 	}
 
-	tradableItems, err := s.skinportClient.GetItems(ctx, true)
+	return catalogue, nil
+}
+
+// refreshItems fetches the catalogue and replaces the cached one. Until it returns,
+// readers keep getting the previous cached snapshot. It shares the fetch with a cache miss of GetItems
+// in flight for the same catalogue, so the two never spend the rate limit twice.
+func (s *Service) refreshItems(ctx context.Context, params models.CatalogueParams) (*Catalogue, error) {
+	entry, err := s.cache.Reload(ctx, catalogueCacheKey(skinportItemsCacheKey, params), s.defaultCacheTTL, func(ctx context.Context) (any, error) {
+		return s.fetchItems(ctx, params)
+	})
 	if err != nil {
-		log.Printf("[ERROR] GetItems: failed to get tradable items: %v\n", err)
 		return nil, err
 	}
 
-	nonTradableItems, err := s.skinportClient.GetItems(ctx, false)
+	catalogue, ok := entry.(*Catalogue)
+	if !ok {
+		return nil, fmt.Errorf("failed to refresh items: %w", errs.ErrInvalidCacheEntry)
+	}
+
+	return catalogue, nil
+}
+
+// fetchItems streams both sides of the catalogue from Skinport concurrently into one merger, so that neither
+// side is held in memory as a whole, and builds a new catalogue snapshot.
+// With the fail policy an error on one side cancels the other one; with the partial policy the side that
+// succeeded is returned as a partial catalogue. Complete snapshots are also kept for maxStale past the TTL
+// to be served when Skinport is unavailable, and their prices are saved to the database.
+func (s *Service) fetchItems(ctx context.Context, params models.CatalogueParams) (*Catalogue, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg                          sync.WaitGroup
+		errOnce                     sync.Once
+		firstErr                    error
+		tradableErr, nonTradableErr error
+	)
+
+	merger := newItemsMerger(catalogueSizeHint)
+	add := func(item skinport.Item) error {
+		merger.add(item)
+		return nil
+	}
+
+	fetch := func(tradable bool, err *error) {
+		defer wg.Done()
+
+		*err = s.skinportClient.StreamItems(ctx, params.AppID, params.Currency, tradable, add)
+		if *err == nil {
+			return
+		}
+
+		log.Printf("[ERROR] GetItems: failed to get items (tradable=%t): %v\n", tradable, *err)
+		errOnce.Do(func() { firstErr = *err })
+		if !s.allowPartialCatalogue {
+			cancel()
+		}
+	}
+
+	wg.Add(2)
+	go fetch(true, &tradableErr)
+	go fetch(false, &nonTradableErr)
+	wg.Wait()
+
+	var warning string
+	switch {
+	case tradableErr == nil && nonTradableErr == nil:
+	case tradableErr != nil && nonTradableErr != nil, !s.allowPartialCatalogue:
+		return nil, firstErr
+	case tradableErr != nil:
+		merger.dropSide(true)
+		warning = fmt.Sprintf("tradable items are unavailable: %v", tradableErr)
+	default:
+		merger.dropSide(false)
+		warning = fmt.Sprintf("non-tradable items are unavailable: %v", nonTradableErr)
+	}
+
+	catalogue := newCatalogue(params, merger.items())
+	catalogue.FetchedAt = time.Now()
+	catalogue.ModifiedAt = catalogue.FetchedAt
+	catalogue.ExpiresAt = catalogue.FetchedAt.Add(s.defaultCacheTTL)
+
+	if warning != "" {
+		log.Printf("[WARN] GetItems: returning partial catalogue: %s\n", warning)
+		catalogue.Partial = true
+		catalogue.Warning = warning
+		catalogue.Version = s.changes.version(params)
+		return catalogue, nil
+	}
+
+	var changes []models.ItemChange
+	catalogue.Version, changes = s.changes.record(catalogue)
+	s.stream.publish(catalogue, changes)
+
+	if s.maxStale > 0 {
+		s.cache.Set(catalogueCacheKey(skinportItemsCacheKey, params)+staleCacheKeySuffix, catalogue, s.defaultCacheTTL+s.maxStale)
+	}
+
+	s.saveSnapshot(catalogue)
+	s.checkAlerts(catalogue)
+
+	return catalogue, nil
+}
+
+func (s *Service) GetItem(ctx context.Context, params models.CatalogueParams, marketHashName string) (*models.ItemResponse, models.CatalogueSnapshot, error) {
+	catalogue, err := s.GetItems(ctx, params)
 	if err != nil {
-		log.Printf("[ERROR] GetItems: failed to get non-tradable items: %v\n", err)
-		return nil, err
+		return nil, models.CatalogueSnapshot{}, err
+	}
+
+	item, ok := catalogue.Item(marketHashName)
+	if !ok {
+		return nil, catalogue.Snapshot(), fmt.Errorf("item %q: %w", marketHashName, errs.ErrItemNotFound)
+	}
+
+	return item, catalogue.Snapshot(), nil
+}
+
+func (s *Service) LookupItems(ctx context.Context, in models.ItemsLookupRequest) (*models.ItemsLookupResponse, models.CatalogueSnapshot, error) {
+	if err := in.Validate(); err != nil {
+		err = errors.Join(errs.ErrValidationFailed, err)
+		log.Printf("validation error in lookup items: %v", err)
+		return nil, models.CatalogueSnapshot{}, err
 	}
 
-	items := mergeItems(tradableItems, nonTradableItems)
+	catalogue, err := s.GetItems(ctx, in.CatalogueParams)
+	if err != nil {
+		return nil, models.CatalogueSnapshot{}, err
+	}
 
-	s.cacheItems(items)
+	result := &models.ItemsLookupResponse{
+		Items:   make([]*models.ItemResponse, 0, len(in.MarketHashNames)),
+		Missing: make([]string, 0),
+	}
+	for _, name := range in.MarketHashNames {
+		item, ok := catalogue.Item(name)
+		if !ok {
+			result.Missing = append(result.Missing, name)
+			continue
+		}
+		result.Items = append(result.Items, item)
+	}
 
-	return items, nil
+	return result, catalogue.Snapshot(), nil
 }
 
+// mergeItems joins the tradable and non-tradable Skinport catalogues by market_hash_name.
+// Both sides of an item must be priced in the same currency, otherwise the non-tradable side is dropped.
 func mergeItems(tradableItems, nonTradableItems []skinport.Item) []*models.ItemResponse {
-	itemResponseMap := make(map[string]*models.ItemResponse)
-
+	merger := newItemsMerger(len(tradableItems))
 	for _, item := range tradableItems {
-		itemResponseMap[item.MarketHashName] = &models.ItemResponse{
-			MarketHashName:   item.MarketHashName,
-			Currency:         item.Currency,
-			MinPriceTradable: item.MinPrice,
+		item.Tradable = true
+		merger.add(item)
+	}
+	for _, item := range nonTradableItems {
+		item.Tradable = false
+		merger.add(item)
+	}
+
+	return merger.items()
+}
+
+// itemsMerger joins both sides of the catalogue item by item as they are decoded, in any order
+// and from several goroutines. Adding an item again replaces its side, so retried requests are safe.
+type itemsMerger struct {
+	mu     sync.Mutex
+	byName map[string]*models.ItemResponse
+}
+
+func newItemsMerger(sizeHint int) *itemsMerger {
+	return &itemsMerger{byName: make(map[string]*models.ItemResponse, sizeHint)}
+}
+
+func (m *itemsMerger) add(item skinport.Item) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	itemResponse, exists := m.byName[item.MarketHashName]
+	switch {
+	case !exists:
+		itemResponse = &models.ItemResponse{MarketHashName: item.MarketHashName, Currency: item.Currency}
+		m.byName[item.MarketHashName] = itemResponse
+	case itemResponse.Currency != item.Currency:
+		tradableCurrency, nonTradableCurrency := itemResponse.Currency, item.Currency
+		if item.Tradable {
+			tradableCurrency, nonTradableCurrency = item.Currency, itemResponse.Currency
+		}
+		log.Printf("[WARN] mergeItems: currency mismatch for %q: tradable %s, non-tradable %s\n",
+			item.MarketHashName, tradableCurrency, nonTradableCurrency)
+
+		if !item.Tradable {
+			return
 		}
+		*itemResponse = models.ItemResponse{MarketHashName: item.MarketHashName, Currency: item.Currency}
 	}
 
-	for _, item := range nonTradableItems {
-		_, exists := itemResponseMap[item.MarketHashName]
-		if exists {
-			itemResponseMap[item.MarketHashName].MinPriceNonTradable = item.MinPrice
-			continue
+	if item.Tradable {
+		itemResponse.MinPriceTradable = item.MinPrice
+		itemResponse.Tradable = newItemStats(item)
+	} else {
+		itemResponse.MinPriceNonTradable = item.MinPrice
+		itemResponse.NonTradable = newItemStats(item)
+	}
+
+	// The tradable suggested price wins, the non-tradable one is used when it is missing.
+	if itemResponse.SuggestedPrice == nil || (item.Tradable && item.SuggestedPrice != nil) {
+		itemResponse.SuggestedPrice = item.SuggestedPrice
+	}
+
+	itemResponse.Availability = availability(itemResponse)
+}
+
+// dropSide removes the prices and stats added for one side, e.g. when its request failed half way.
+// The suggested price is kept, Skinport gives the same one for both sides.
+func (m *itemsMerger) dropSide(tradable bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for name, itemResponse := range m.byName {
+		if tradable {
+			itemResponse.MinPriceTradable = nil
+			itemResponse.Tradable = nil
+		} else {
+			itemResponse.MinPriceNonTradable = nil
+			itemResponse.NonTradable = nil
 		}
 
-		itemResponseMap[item.MarketHashName] = &models.ItemResponse{
-			MarketHashName:      item.MarketHashName,
-			Currency:            item.Currency,
-			MinPriceNonTradable: item.MinPrice,
+		if itemResponse.Tradable == nil && itemResponse.NonTradable == nil {
+			delete(m.byName, name)
+			continue
 		}
+		itemResponse.Availability = availability(itemResponse)
 	}
+}
+
+func (m *itemsMerger) items() []*models.ItemResponse {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
-	itemResponses := make([]*models.ItemResponse, 0, len(itemResponseMap))
-	for _, item := range itemResponseMap {
+	itemResponses := make([]*models.ItemResponse, 0, len(m.byName))
+	for _, item := range m.byName {
 		itemResponses = append(itemResponses, item)
 	}
 
 	return itemResponses
 }
 
-func (s *Service) getCachedItems() ([]*models.ItemResponse, error) {
-	entry, err := s.cache.Get(skinportItemsCacheKey)
-	if err != nil {
-		return nil, err
+func availability(item *models.ItemResponse) models.Availability {
+	switch {
+	case item.Tradable != nil && item.NonTradable != nil:
+		return models.AvailabilityBoth
+	case item.Tradable != nil:
+		return models.AvailabilityTradableOnly
+	default:
+		return models.AvailabilityNonTradableOnly
 	}
+}
 
-	result, ok := entry.([]*models.ItemResponse)
-	if !ok {
-		return nil, fmt.Errorf("failed to get items: %w", errs.ErrInvalidCacheEntry)
+func newItemStats(item skinport.Item) *models.ItemStats {
+	return &models.ItemStats{
+		MinPrice:    item.MinPrice,
+		MaxPrice:    item.MaxPrice,
+		MeanPrice:   item.MeanPrice,
+		MedianPrice: item.MedianPrice,
+		Quantity:    item.Quantity,
+		ItemPage:    item.ItemPage,
+		MarketPage:  item.MarketPage,
+		CreatedAt:   unixTime(item.CreatedAt),
+		UpdatedAt:   unixTime(item.UpdatedAt),
 	}
+}
 
-	return result, nil
+// unixTime converts Skinport unix timestamps, keeping zero as the zero time.
+func unixTime(sec int64) time.Time {
+	if sec == 0 {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0).UTC()
 }
 
-func (s *Service) cacheItems(items []*models.ItemResponse) {
-	s.cache.Set(skinportItemsCacheKey, items, s.defaultCacheTTL)
+// catalogueParams fills in the configured defaults and checks params against the Skinport supported lists.
+func (s *Service) catalogueParams(params models.CatalogueParams) (models.CatalogueParams, error) {
+	if params.AppID == 0 {
+		params.AppID = s.defaultAppID
+	}
+
+	if params.Currency == "" {
+		params.Currency = s.defaultCurrency
+	}
+	params.Currency = strings.ToUpper(params.Currency)
+
+	if err := skinport.ValidateAppID(params.AppID); err != nil {
+		return params, errors.Join(errs.ErrValidationFailed, err)
+	}
+
+	if err := skinport.ValidateCurrency(params.Currency); err != nil {
+		return params, errors.Join(errs.ErrValidationFailed, err)
+	}
+
+	return params, nil
 }
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"runtime/metrics"
+	"sync"
+	"testing"
+	"time"
+
+	"backend-test-golang/internal/models"
+	"backend-test-golang/pkg/skinport"
+)
+
+// benchmarkCatalogueSize is about the size of the CS2 catalogue on Skinport.
+const benchmarkCatalogueSize = 20000
+
+var benchmarkSink []*models.ItemResponse
+
+// benchmarkCatalogue returns the JSON bodies of the tradable and non-tradable catalogues.
+func benchmarkCatalogue(b *testing.B) (tradable, nonTradable []byte) {
+	b.Helper()
+
+	side := func(tradable bool) []byte {
+		items := make([]skinport.Item, 0, benchmarkCatalogueSize)
+		for i := 0; i < benchmarkCatalogueSize; i++ {
+			price := float64(i) + 0.99
+			items = append(items, skinport.Item{
+				MarketHashName: fmt.Sprintf("AK-47 | Redline (Field-Tested) #%d", i),
+				Currency:       "EUR",
+				SuggestedPrice: &price,
+				ItemPage:       fmt.Sprintf("https://skinport.com/item/ak-47-redline-field-tested-%d", i),
+				MarketPage:     fmt.Sprintf("https://skinport.com/market?item=AK-47%%20Redline%%20%d", i),
+				MinPrice:       &price,
+				MaxPrice:       &price,
+				MeanPrice:      &price,
+				MedianPrice:    &price,
+				Quantity:       int64(i % 50),
+				CreatedAt:      1535988253,
+				UpdatedAt:      1700000000,
+				Tradable:       tradable,
+			})
+		}
+
+		body, err := json.Marshal(items)
+		if err != nil {
+			b.Fatal(err)
+		}
+		return body
+	}
+
+	return side(true), side(false)
+}
+
+// peakHeap runs fn and returns how much the heap grew at most while it ran. The heap is sampled
+// every 100µs with runtime/metrics, so the result is approximate and includes not yet collected garbage.
+func peakHeap(b *testing.B, fn func()) uint64 {
+	sample := []metrics.Sample{{Name: "/memory/classes/heap/objects:bytes"}}
+	read := func() uint64 {
+		metrics.Read(sample)
+		return sample[0].Value.Uint64()
+	}
+
+	b.StopTimer()
+	runtime.GC()
+	b.StartTimer()
+	base := read()
+	peak := base
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(100 * time.Microsecond)
+		defer ticker.Stop()
+		for {
+			peak = max(peak, read())
+			select {
+			case <-ticker.C:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	fn()
+	close(done)
+	wg.Wait()
+	peak = max(peak, read())
+
+	return peak - base
+}
+
+// BenchmarkFetchCatalogue compares decoding both sides into slices and merging them afterwards
+// with streaming them item by item into the merger, as fetchItems does.
+func BenchmarkFetchCatalogue(b *testing.B) {
+	tradable, nonTradable := benchmarkCatalogue(b)
+
+	benchmarks := []struct {
+		name  string
+		fetch func() []*models.ItemResponse
+	}{
+		{
+			name: "buffered",
+			fetch: func() []*models.ItemResponse {
+				var tradableItems, nonTradableItems []skinport.Item
+				if err := json.NewDecoder(bytes.NewReader(tradable)).Decode(&tradableItems); err != nil {
+					b.Fatal(err)
+				}
+				if err := json.NewDecoder(bytes.NewReader(nonTradable)).Decode(&nonTradableItems); err != nil {
+					b.Fatal(err)
+				}
+				return mergeItems(tradableItems, nonTradableItems)
+			},
+		},
+		{
+			name: "streaming",
+			fetch: func() []*models.ItemResponse {
+				merger := newItemsMerger(catalogueSizeHint)
+				add := func(item skinport.Item) error {
+					merger.add(item)
+					return nil
+				}
+				if err := skinport.DecodeItems(bytes.NewReader(tradable), add); err != nil {
+					b.Fatal(err)
+				}
+				if err := skinport.DecodeItems(bytes.NewReader(nonTradable), add); err != nil {
+					b.Fatal(err)
+				}
+				return merger.items()
+			},
+		},
+	}
+
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+
+			var peak uint64
+			for i := 0; i < b.N; i++ {
+				peak = max(peak, peakHeap(b, func() { benchmarkSink = bm.fetch() }))
+			}
+
+			b.ReportMetric(float64(peak)/(1<<20), "peak-heap-MB")
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"backend-test-golang/internal/config"
+	"backend-test-golang/internal/models"
+	"backend-test-golang/pkg/skinport"
+)
+
+func TestService_FetchItems(t *testing.T) {
+	tradablePrice, nonTradablePrice := 10.00, 9.00
+	tradable := []skinport.Item{
+		{MarketHashName: "Both Sides", Currency: "EUR", MinPrice: &tradablePrice},
+		{MarketHashName: "Tradable Only", Currency: "EUR", MinPrice: &tradablePrice},
+	}
+	nonTradable := []skinport.Item{
+		{MarketHashName: "Both Sides", Currency: "EUR", MinPrice: &nonTradablePrice},
+	}
+	params := models.CatalogueParams{AppID: 730, Currency: "EUR"}
+
+	t.Run("both sides are fetched in parallel", func(t *testing.T) {
+		f := newFakeSkinport(t, tradable, nonTradable)
+		f.tradable.delay.Store(int64(300 * time.Millisecond))
+		f.nonTradable.delay.Store(int64(300 * time.Millisecond))
+		svc := newTestService(t, f, 300)
+
+		start := time.Now()
+		catalogue, err := svc.fetchItems(context.Background(), params)
+		if err != nil {
+			t.Fatalf("got unexpected error: %v", err)
+		}
+
+		if elapsed := time.Since(start); elapsed >= 550*time.Millisecond {
+			t.Errorf("fetches should run concurrently, took %v", elapsed)
+		}
+		if len(catalogue.Items) != 2 || catalogue.Partial {
+			t.Errorf("expected complete catalogue of 2 items, got %d items, partial=%t", len(catalogue.Items), catalogue.Partial)
+		}
+	})
+
+	t.Run("failure cancels the other fetch", func(t *testing.T) {
+		f := newFakeSkinport(t, tradable, nonTradable)
+		f.tradable.status.Store(http.StatusInternalServerError)
+		// The 500 must arrive while the non-tradable request is in flight for there to be something to cancel.
+		f.tradable.waitStarted.Store(&f.nonTradable)
+		f.nonTradable.delay.Store(int64(5 * time.Second))
+		svc := newTestService(t, f, 300)
+
+		start := time.Now()
+		_, err := svc.fetchItems(context.Background(), params)
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		if elapsed := time.Since(start); elapsed >= time.Second {
+			t.Errorf("failure should cancel the other fetch, took %v", elapsed)
+		}
+
+		waitFor(t, time.Second, func() bool { return f.cancelled.Load() == 1 })
+	})
+
+	t.Run("failure with partial policy returns the other side", func(t *testing.T) {
+		f := newFakeSkinport(t, tradable, nonTradable)
+		f.nonTradable.status.Store(http.StatusBadGateway)
+		svc := newTestServiceWithConfig(t, f, &config.Config{
+			CacheTTLSeconds:       300,
+			CacheMaxStaleSeconds:  3600,
+			SkinportPartialPolicy: PartialPolicyPartial,
+		})
+
+		catalogue, err := svc.fetchItems(context.Background(), params)
+		if err != nil {
+			t.Fatalf("got unexpected error: %v", err)
+		}
+		if !catalogue.Partial || catalogue.Warning == "" {
+			t.Errorf("expected partial catalogue with a warning, got partial=%t warning=%q", catalogue.Partial, catalogue.Warning)
+		}
+		if len(catalogue.Items) != 2 {
+			t.Fatalf("expected 2 tradable items, got %d", len(catalogue.Items))
+		}
+		if item, _ := catalogue.Item("Both Sides"); item.MinPriceNonTradable != nil {
+			t.Errorf("expected no non-tradable price, got %v", *item.MinPriceNonTradable)
+		}
+
+		staleKey := catalogueCacheKey(skinportItemsCacheKey, params) + staleCacheKeySuffix
+		if _, err = svc.cache.Get(staleKey); err == nil {
+			t.Error("partial catalogue must not replace the last complete snapshot")
+		}
+	})
+
+	t.Run("side failing half way is dropped with partial policy", func(t *testing.T) {
+		f := newFakeSkinport(t, tradable, nonTradable)
+		f.tradable.truncate.Store(true)
+		svc := newTestServiceWithConfig(t, f, &config.Config{
+			CacheTTLSeconds:       300,
+			SkinportPartialPolicy: PartialPolicyPartial,
+		})
+
+		catalogue, err := svc.fetchItems(context.Background(), params)
+		if err != nil {
+			t.Fatalf("got unexpected error: %v", err)
+		}
+		if !catalogue.Partial {
+			t.Error("expected partial catalogue")
+		}
+		if len(catalogue.Items) != 1 {
+			t.Fatalf("expected only the non-tradable item, got %d items", len(catalogue.Items))
+		}
+
+		item, _ := catalogue.Item("Both Sides")
+		if item.MinPriceTradable != nil || item.Tradable != nil || item.Availability != models.AvailabilityNonTradableOnly {
+			t.Errorf("expected items decoded before the failure to be dropped, got %+v", item)
+		}
+	})
+
+	t.Run("both sides failing is an error with partial policy", func(t *testing.T) {
+		f := newFakeSkinport(t, tradable, nonTradable)
+		f.failWith(http.StatusServiceUnavailable)
+		svc := newTestServiceWithConfig(t, f, &config.Config{
+			CacheTTLSeconds:       300,
+			SkinportPartialPolicy: PartialPolicyPartial,
+		})
+
+		if _, err := svc.fetchItems(context.Background(), params); err == nil {
+			t.Error("expected error")
+		}
+	})
+}
@@ -0,0 +1,137 @@
+package services
+
+import (
+	"backend-test-golang/internal/models"
+	errs "backend-test-golang/pkg/errors"
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"strings"
+)
+
+// SearchItems filters, sorts and paginates the cached catalogue. It never calls Skinport on its own,
+// the catalogue is loaded through GetItems.
+func (s *Service) SearchItems(ctx context.Context, q models.ItemsQuery) (*models.ItemsPage, models.CatalogueSnapshot, error) {
+	if err := q.Validate(); err != nil {
+		err = errors.Join(errs.ErrValidationFailed, err)
+		log.Printf("validation error in search items: %v", err)
+		return nil, models.CatalogueSnapshot{}, err
+	}
+
+	catalogue, err := s.GetItems(ctx, q.CatalogueParams)
+	if err != nil {
+		return nil, models.CatalogueSnapshot{}, err
+	}
+
+	return searchItems(catalogue.Items, q), catalogue.Snapshot(), nil
+}
+
+func searchItems(items []*models.ItemResponse, q models.ItemsQuery) *models.ItemsPage {
+	filtered := make([]*models.ItemResponse, 0, len(items))
+	for _, item := range items {
+		if matchItem(item, q) {
+			filtered = append(filtered, item)
+		}
+	}
+
+	sortItems(filtered, q.SortBy, q.Desc)
+
+	limit := q.Limit
+	switch {
+	case limit > 0:
+	case q.Format == models.ItemsFormatCSV || q.Format == models.ItemsFormatNDJSON:
+		limit = len(filtered)
+	default:
+		limit = models.DefaultItemsLimit
+	}
+
+	start := min(q.Offset, len(filtered))
+	end := min(start+limit, len(filtered))
+
+	return &models.ItemsPage{
+		Items:  filtered[start:end],
+		Total:  len(filtered),
+		Limit:  limit,
+		Offset: q.Offset,
+	}
+}
+
+func matchItem(item *models.ItemResponse, q models.ItemsQuery) bool {
+	if q.Name != "" && !strings.Contains(strings.ToLower(item.MarketHashName), strings.ToLower(q.Name)) {
+		return false
+	}
+
+	if q.NamePrefix != "" && !strings.HasPrefix(strings.ToLower(item.MarketHashName), strings.ToLower(q.NamePrefix)) {
+		return false
+	}
+
+	if q.Availability != "" && item.Availability != q.Availability {
+		return false
+	}
+
+	return inPriceRange(item.MinPriceTradable, q.MinPriceTradable, q.MaxPriceTradable) &&
+		inPriceRange(item.MinPriceNonTradable, q.MinPriceNonTradable, q.MaxPriceNonTradable)
+}
+
+// inPriceRange reports whether price is within [low, high]. Items without a price never match a range.
+func inPriceRange(price, low, high *float64) bool {
+	if low == nil && high == nil {
+		return true
+	}
+
+	if price == nil {
+		return false
+	}
+
+	if low != nil && *price < *low {
+		return false
+	}
+
+	if high != nil && *price > *high {
+		return false
+	}
+
+	return true
+}
+
+// sortItems orders items in place. Ties are broken by name, items without a price always go last.
+func sortItems(items []*models.ItemResponse, sortBy models.ItemsSort, desc bool) {
+	byName := func(a, b *models.ItemResponse) bool {
+		return a.MarketHashName < b.MarketHashName
+	}
+
+	var price func(item *models.ItemResponse) *float64
+	switch sortBy {
+	case models.ItemsSortPriceTradable:
+		price = func(item *models.ItemResponse) *float64 { return item.MinPriceTradable }
+	case models.ItemsSortPriceNonTradable:
+		price = func(item *models.ItemResponse) *float64 { return item.MinPriceNonTradable }
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if price == nil {
+			if desc {
+				return byName(b, a)
+			}
+			return byName(a, b)
+		}
+
+		pa, pb := price(a), price(b)
+		switch {
+		case pa == nil && pb == nil:
+			return byName(a, b)
+		case pa == nil:
+			return false
+		case pb == nil:
+			return true
+		case *pa == *pb:
+			return byName(a, b)
+		case desc:
+			return *pa > *pb
+		default:
+			return *pa < *pb
+		}
+	})
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+
+	"backend-test-golang/internal/models"
+)
+
+func testCatalogue() []*models.ItemResponse {
+	price := func(v float64) *float64 { return &v }
+
+	return []*models.ItemResponse{
+		{MarketHashName: "AWP | Asiimov (Field-Tested)", MinPriceTradable: price(85), MinPriceNonTradable: price(82), Availability: models.AvailabilityBoth},
+		{MarketHashName: "AK-47 | Redline (Field-Tested)", MinPriceTradable: price(25.99), MinPriceNonTradable: price(23.5), Availability: models.AvailabilityBoth},
+		{MarketHashName: "M4A4 | Howl (Factory New)", MinPriceNonTradable: price(4500), Availability: models.AvailabilityNonTradableOnly},
+		{MarketHashName: "AK-47 | Slate (Minimal Wear)", MinPriceTradable: price(3.1), Availability: models.AvailabilityTradableOnly},
+		{MarketHashName: "Sticker | Crown (Foil)", Availability: models.AvailabilityTradableOnly},
+	}
+}
+
+func names(items []*models.ItemResponse) []string {
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		result = append(result, item.MarketHashName)
+	}
+	return result
+}
+
+func equalNames(got []*models.ItemResponse, want ...string) bool {
+	gotNames := names(got)
+	if len(gotNames) != len(want) {
+		return false
+	}
+	for i := range want {
+		if gotNames[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSearchItems(t *testing.T) {
+	price := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name      string
+		query     models.ItemsQuery
+		wantNames []string
+		wantTotal int
+	}{
+		{
+			name:      "default sorts by name",
+			query:     models.ItemsQuery{},
+			wantNames: []string{"AK-47 | Redline (Field-Tested)", "AK-47 | Slate (Minimal Wear)", "AWP | Asiimov (Field-Tested)", "M4A4 | Howl (Factory New)", "Sticker | Crown (Foil)"},
+			wantTotal: 5,
+		},
+		{
+			name:      "name substring is case insensitive",
+			query:     models.ItemsQuery{Name: "field-tested"},
+			wantNames: []string{"AK-47 | Redline (Field-Tested)", "AWP | Asiimov (Field-Tested)"},
+			wantTotal: 2,
+		},
+		{
+			name:      "name prefix",
+			query:     models.ItemsQuery{NamePrefix: "ak-47"},
+			wantNames: []string{"AK-47 | Redline (Field-Tested)", "AK-47 | Slate (Minimal Wear)"},
+			wantTotal: 2,
+		},
+		{
+			name:      "tradable price range skips items without tradable price",
+			query:     models.ItemsQuery{MinPriceTradable: price(3), MaxPriceTradable: price(30)},
+			wantNames: []string{"AK-47 | Redline (Field-Tested)", "AK-47 | Slate (Minimal Wear)"},
+			wantTotal: 2,
+		},
+		{
+			name:      "non-tradable min price",
+			query:     models.ItemsQuery{MinPriceNonTradable: price(50)},
+			wantNames: []string{"AWP | Asiimov (Field-Tested)", "M4A4 | Howl (Factory New)"},
+			wantTotal: 2,
+		},
+		{
+			name:      "only tradable",
+			query:     models.ItemsQuery{Availability: models.AvailabilityTradableOnly},
+			wantNames: []string{"AK-47 | Slate (Minimal Wear)", "Sticker | Crown (Foil)"},
+			wantTotal: 2,
+		},
+		{
+			name:      "only non-tradable",
+			query:     models.ItemsQuery{Availability: models.AvailabilityNonTradableOnly},
+			wantNames: []string{"M4A4 | Howl (Factory New)"},
+			wantTotal: 1,
+		},
+		{
+			name:      "sort by tradable price puts missing prices last",
+			query:     models.ItemsQuery{SortBy: models.ItemsSortPriceTradable},
+			wantNames: []string{"AK-47 | Slate (Minimal Wear)", "AK-47 | Redline (Field-Tested)", "AWP | Asiimov (Field-Tested)", "M4A4 | Howl (Factory New)", "Sticker | Crown (Foil)"},
+			wantTotal: 5,
+		},
+		{
+			name:      "sort by tradable price descending",
+			query:     models.ItemsQuery{SortBy: models.ItemsSortPriceTradable, Desc: true},
+			wantNames: []string{"AWP | Asiimov (Field-Tested)", "AK-47 | Redline (Field-Tested)", "AK-47 | Slate (Minimal Wear)", "M4A4 | Howl (Factory New)", "Sticker | Crown (Foil)"},
+			wantTotal: 5,
+		},
+		{
+			name:      "sort by name descending",
+			query:     models.ItemsQuery{SortBy: models.ItemsSortName, Desc: true},
+			wantNames: []string{"Sticker | Crown (Foil)", "M4A4 | Howl (Factory New)", "AWP | Asiimov (Field-Tested)", "AK-47 | Slate (Minimal Wear)", "AK-47 | Redline (Field-Tested)"},
+			wantTotal: 5,
+		},
+		{
+			name:      "pagination keeps total",
+			query:     models.ItemsQuery{Limit: 2, Offset: 1},
+			wantNames: []string{"AK-47 | Slate (Minimal Wear)", "AWP | Asiimov (Field-Tested)"},
+			wantTotal: 5,
+		},
+		{
+			name:      "offset past the end",
+			query:     models.ItemsQuery{Offset: 10},
+			wantNames: []string{},
+			wantTotal: 5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			catalogue := testCatalogue()
+			page := searchItems(catalogue, tt.query)
+
+			if page.Total != tt.wantTotal {
+				t.Errorf("got total %d, want %d", page.Total, tt.wantTotal)
+			}
+			if !equalNames(page.Items, tt.wantNames...) {
+				t.Errorf("got items %v, want %v", names(page.Items), tt.wantNames)
+			}
+			if catalogue[0].MarketHashName != "AWP | Asiimov (Field-Tested)" {
+				t.Error("search must not reorder the cached catalogue")
+			}
+		})
+	}
+}
+
+func TestSearchItems_ExportLimit(t *testing.T) {
+	items := make([]*models.ItemResponse, models.DefaultItemsLimit+50)
+	for i := range items {
+		items[i] = &models.ItemResponse{MarketHashName: fmt.Sprintf("Item %03d", i)}
+	}
+
+	if page := searchItems(items, models.ItemsQuery{}); len(page.Items) != models.DefaultItemsLimit {
+		t.Errorf("json page got %d items, want the default limit %d", len(page.Items), models.DefaultItemsLimit)
+	}
+
+	for _, format := range []models.ItemsFormat{models.ItemsFormatCSV, models.ItemsFormatNDJSON} {
+		if page := searchItems(items, models.ItemsQuery{Format: format}); len(page.Items) != len(items) || page.Limit != len(items) {
+			t.Errorf("%s export got %d items, want all %d", format, len(page.Items), len(items))
+		}
+		if page := searchItems(items, models.ItemsQuery{Format: format, Limit: 10}); len(page.Items) != 10 {
+			t.Errorf("%s export with limit got %d items, want 10", format, len(page.Items))
+		}
+	}
+}
@@ -1,9 +1,18 @@
 package services
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
 	"testing"
+	"time"
 
+	"backend-test-golang/internal/config"
 	"backend-test-golang/internal/models"
+	"backend-test-golang/pkg/circuitbreaker"
+	errs "backend-test-golang/pkg/errors"
 	"backend-test-golang/pkg/skinport"
 )
 
@@ -202,3 +211,367 @@ func TestMergeItems_Consistency(t *testing.T) {
 		}
 	})
 }
+
+func TestMergeItems_Statistics(t *testing.T) {
+	t.Run("statistics of both sides are kept", func(t *testing.T) {
+		suggested := 30.00
+		tMin, tMax, tMean, tMedian := 25.99, 40.00, 28.10, 27.50
+		ntMin, ntMax := 23.50, 35.00
+
+		tradableItems := []skinport.Item{
+			{
+				MarketHashName: "AK-47 | Redline (Field-Tested)",
+				Currency:       "EUR",
+				SuggestedPrice: &suggested,
+				ItemPage:       "https://skinport.com/item/ak-47-redline-field-tested",
+				MarketPage:     "https://skinport.com/market?item=AK-47%20%7C%20Redline",
+				MinPrice:       &tMin,
+				MaxPrice:       &tMax,
+				MeanPrice:      &tMean,
+				MedianPrice:    &tMedian,
+				Quantity:       12,
+				CreatedAt:      1535988253,
+				UpdatedAt:      1700000000,
+			},
+		}
+
+		nonTradableItems := []skinport.Item{
+			{
+				MarketHashName: "AK-47 | Redline (Field-Tested)",
+				Currency:       "EUR",
+				MinPrice:       &ntMin,
+				MaxPrice:       &ntMax,
+				Quantity:       3,
+				UpdatedAt:      1700000100,
+			},
+		}
+
+		result := mergeItems(tradableItems, nonTradableItems)
+
+		if len(result) != 1 {
+			t.Fatalf("expected 1 item, got %d", len(result))
+		}
+
+		item := result[0]
+		if item.Availability != models.AvailabilityBoth {
+			t.Errorf("expected availability %q, got %q", models.AvailabilityBoth, item.Availability)
+		}
+		if item.SuggestedPrice == nil || *item.SuggestedPrice != suggested {
+			t.Errorf("expected suggested price %.2f, got %v", suggested, item.SuggestedPrice)
+		}
+		if item.Tradable == nil || item.NonTradable == nil {
+			t.Fatalf("expected statistics of both sides, got tradable=%v non-tradable=%v", item.Tradable, item.NonTradable)
+		}
+		if item.Tradable.Quantity != 12 || item.NonTradable.Quantity != 3 {
+			t.Errorf("expected quantities 12/3, got %d/%d", item.Tradable.Quantity, item.NonTradable.Quantity)
+		}
+		if item.Tradable.MedianPrice == nil || *item.Tradable.MedianPrice != tMedian {
+			t.Errorf("expected tradable median price %.2f, got %v", tMedian, item.Tradable.MedianPrice)
+		}
+		if item.NonTradable.MaxPrice == nil || *item.NonTradable.MaxPrice != ntMax {
+			t.Errorf("expected non-tradable max price %.2f, got %v", ntMax, item.NonTradable.MaxPrice)
+		}
+		if item.Tradable.ItemPage != tradableItems[0].ItemPage {
+			t.Errorf("expected item page %q, got %q", tradableItems[0].ItemPage, item.Tradable.ItemPage)
+		}
+		if item.NonTradable.UpdatedAt.Unix() != 1700000100 {
+			t.Errorf("expected non-tradable updated_at 1700000100, got %d", item.NonTradable.UpdatedAt.Unix())
+		}
+		if !item.NonTradable.CreatedAt.IsZero() {
+			t.Errorf("expected zero non-tradable created_at, got %v", item.NonTradable.CreatedAt)
+		}
+	})
+
+	t.Run("one sided items are flagged", func(t *testing.T) {
+		price := 10.00
+
+		result := mergeItems(
+			[]skinport.Item{{MarketHashName: "Tradable Item", Currency: "EUR", MinPrice: &price}},
+			[]skinport.Item{{MarketHashName: "Non-Tradable Item", Currency: "EUR", MinPrice: &price}},
+		)
+
+		itemMap := make(map[string]*models.ItemResponse)
+		for _, item := range result {
+			itemMap[item.MarketHashName] = item
+		}
+
+		if got := itemMap["Tradable Item"].Availability; got != models.AvailabilityTradableOnly {
+			t.Errorf("expected availability %q, got %q", models.AvailabilityTradableOnly, got)
+		}
+		if itemMap["Tradable Item"].NonTradable != nil {
+			t.Error("tradable only item should not have non-tradable statistics")
+		}
+		if got := itemMap["Non-Tradable Item"].Availability; got != models.AvailabilityNonTradableOnly {
+			t.Errorf("expected availability %q, got %q", models.AvailabilityNonTradableOnly, got)
+		}
+		if itemMap["Non-Tradable Item"].Tradable != nil {
+			t.Error("non-tradable only item should not have tradable statistics")
+		}
+	})
+
+	t.Run("currency mismatch keeps tradable side only", func(t *testing.T) {
+		tradablePrice := 10.00
+		nonTradablePrice := 11.00
+
+		result := mergeItems(
+			[]skinport.Item{{MarketHashName: "Item", Currency: "EUR", MinPrice: &tradablePrice}},
+			[]skinport.Item{{MarketHashName: "Item", Currency: "USD", MinPrice: &nonTradablePrice}},
+		)
+
+		if len(result) != 1 {
+			t.Fatalf("expected 1 item, got %d", len(result))
+		}
+
+		item := result[0]
+		if item.Currency != "EUR" {
+			t.Errorf("expected currency 'EUR', got '%s'", item.Currency)
+		}
+		if item.MinPriceNonTradable != nil || item.NonTradable != nil {
+			t.Errorf("expected non-tradable side to be dropped, got %v", item.MinPriceNonTradable)
+		}
+		if item.Availability != models.AvailabilityTradableOnly {
+			t.Errorf("expected availability %q, got %q", models.AvailabilityTradableOnly, item.Availability)
+		}
+	})
+}
+
+func TestItemsMerger(t *testing.T) {
+	tradablePrice, nonTradablePrice, suggestedPrice := 25.99, 23.50, 30.00
+
+	t.Run("sides may arrive in any order", func(t *testing.T) {
+		merger := newItemsMerger(1)
+		merger.add(skinport.Item{MarketHashName: "Item", Currency: "EUR", MinPrice: &nonTradablePrice, SuggestedPrice: &suggestedPrice})
+		merger.add(skinport.Item{MarketHashName: "Item", Currency: "EUR", MinPrice: &tradablePrice, Tradable: true})
+
+		items := merger.items()
+		if len(items) != 1 {
+			t.Fatalf("expected 1 item, got %d", len(items))
+		}
+		item := items[0]
+		if item.Availability != models.AvailabilityBoth || *item.MinPriceTradable != tradablePrice || *item.MinPriceNonTradable != nonTradablePrice {
+			t.Errorf("expected both sides merged, got %+v", item)
+		}
+		if item.SuggestedPrice == nil || *item.SuggestedPrice != suggestedPrice {
+			t.Errorf("expected non-tradable suggested price when tradable has none, got %v", item.SuggestedPrice)
+		}
+	})
+
+	t.Run("late tradable side with other currency replaces non-tradable side", func(t *testing.T) {
+		merger := newItemsMerger(1)
+		merger.add(skinport.Item{MarketHashName: "Item", Currency: "USD", MinPrice: &nonTradablePrice})
+		merger.add(skinport.Item{MarketHashName: "Item", Currency: "EUR", MinPrice: &tradablePrice, Tradable: true})
+
+		item := merger.items()[0]
+		if item.Currency != "EUR" || item.NonTradable != nil || item.Availability != models.AvailabilityTradableOnly {
+			t.Errorf("expected tradable EUR side only, got %+v", item)
+		}
+	})
+
+	t.Run("adding an item again replaces its side", func(t *testing.T) {
+		merger := newItemsMerger(1)
+		merger.add(skinport.Item{MarketHashName: "Item", Currency: "EUR", MinPrice: &nonTradablePrice, Tradable: true})
+		merger.add(skinport.Item{MarketHashName: "Item", Currency: "EUR", MinPrice: &tradablePrice, Tradable: true})
+
+		items := merger.items()
+		if len(items) != 1 || *items[0].MinPriceTradable != tradablePrice {
+			t.Errorf("expected the last tradable price, got %+v", items)
+		}
+	})
+
+	t.Run("concurrent adds", func(t *testing.T) {
+		merger := newItemsMerger(100)
+
+		var wg sync.WaitGroup
+		for _, tradable := range []bool{true, false} {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 100; i++ {
+					merger.add(skinport.Item{MarketHashName: fmt.Sprintf("Item %d", i), Currency: "EUR", MinPrice: &tradablePrice, Tradable: tradable})
+				}
+			}()
+		}
+		wg.Wait()
+
+		items := merger.items()
+		if len(items) != 100 {
+			t.Fatalf("expected 100 items, got %d", len(items))
+		}
+		for _, item := range items {
+			if item.Availability != models.AvailabilityBoth {
+				t.Fatalf("expected both sides of %q, got %s", item.MarketHashName, item.Availability)
+			}
+		}
+	})
+}
+
+func TestService_CatalogueParams(t *testing.T) {
+	s := &Service{defaultAppID: 730, defaultCurrency: "EUR"}
+
+	tests := []struct {
+		name    string
+		params  models.CatalogueParams
+		want    models.CatalogueParams
+		wantErr bool
+	}{
+		{name: "defaults", params: models.CatalogueParams{}, want: models.CatalogueParams{AppID: 730, Currency: "EUR"}},
+		{name: "explicit app and currency", params: models.CatalogueParams{AppID: 570, Currency: "usd"}, want: models.CatalogueParams{AppID: 570, Currency: "USD"}},
+		{name: "unsupported app", params: models.CatalogueParams{AppID: 1}, wantErr: true},
+		{name: "unsupported currency", params: models.CatalogueParams{Currency: "XYZ"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.catalogueParams(tt.params)
+			if tt.wantErr {
+				if !errors.Is(err, errs.ErrValidationFailed) {
+					t.Errorf("got error %v, want %v", err, errs.ErrValidationFailed)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("got unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+			if key := catalogueCacheKey(skinportItemsCacheKey, got); key != fmt.Sprintf("skinport:items:%d:%s", tt.want.AppID, tt.want.Currency) {
+				t.Errorf("unexpected cache key %q", key)
+			}
+		})
+	}
+}
+
+func TestService_GetItems_Stale(t *testing.T) {
+	price := 10.00
+	f := newFakeSkinport(t,
+		[]skinport.Item{{MarketHashName: "Item", Currency: "EUR", MinPrice: &price}},
+		nil,
+	)
+	params := models.CatalogueParams{}
+	cacheKey := catalogueCacheKey(skinportItemsCacheKey, models.CatalogueParams{AppID: 730, Currency: "EUR"})
+
+	t.Run("stale catalogue is served when skinport fails", func(t *testing.T) {
+		svc := newTestServiceWithConfig(t, f, &config.Config{CacheTTLSeconds: 300, CacheMaxStaleSeconds: 3600})
+		defer f.failWith(0)
+
+		fresh, err := svc.GetItems(context.Background(), params)
+		if err != nil {
+			t.Fatalf("got unexpected error: %v", err)
+		}
+		if fresh.Stale {
+			t.Error("fresh catalogue must not be stale")
+		}
+
+		svc.cache.Delete(cacheKey) // the fresh entry expired
+		f.failWith(http.StatusServiceUnavailable)
+
+		stale, err := svc.GetItems(context.Background(), params)
+		if err != nil {
+			t.Fatalf("got unexpected error: %v", err)
+		}
+		if !stale.Stale {
+			t.Error("expected stale catalogue")
+		}
+		if !stale.FetchedAt.Equal(fresh.FetchedAt) || len(stale.Items) != 1 {
+			t.Errorf("expected the last successful catalogue, got %+v", stale)
+		}
+		if fresh.Stale {
+			t.Error("cached catalogue must not be modified")
+		}
+	})
+
+	t.Run("errors are returned when stale serving is disabled", func(t *testing.T) {
+		svc := newTestServiceWithConfig(t, f, &config.Config{CacheTTLSeconds: 300})
+		defer f.failWith(0)
+
+		if _, err := svc.GetItems(context.Background(), params); err != nil {
+			t.Fatalf("got unexpected error: %v", err)
+		}
+
+		svc.cache.Delete(cacheKey)
+		f.failWith(http.StatusServiceUnavailable)
+
+		if _, err := svc.GetItems(context.Background(), params); err == nil {
+			t.Error("expected error when stale catalogue is not allowed")
+		}
+	})
+}
+
+func TestService_GetItems_CircuitOpen(t *testing.T) {
+	price := 10.00
+	f := newFakeSkinport(t,
+		[]skinport.Item{{MarketHashName: "Item", Currency: "EUR", MinPrice: &price}},
+		nil,
+	)
+	svc := newTestServiceWithConfig(t, f, &config.Config{CacheTTLSeconds: 300, CacheMaxStaleSeconds: 3600})
+	cacheKey := catalogueCacheKey(skinportItemsCacheKey, models.CatalogueParams{AppID: 730, Currency: "EUR"})
+	defer f.failWith(0)
+
+	client, err := skinport.NewClient("", "", f.server.URL, skinport.WithCircuitBreaker(circuitbreaker.New("test", 1, time.Minute, 1)))
+	if err != nil {
+		t.Fatalf("failed to create skinport client: %v", err)
+	}
+	svc.skinportClient = client
+
+	if _, err := svc.GetItems(context.Background(), models.CatalogueParams{}); err != nil {
+		t.Fatalf("got unexpected error: %v", err)
+	}
+
+	f.failWith(http.StatusServiceUnavailable)
+	// Both failing requests reach the fake before the breaker opens, so none of them is counted late below.
+	f.tradable.waitStarted.Store(&f.nonTradable)
+	svc.cache.Delete(cacheKey)
+	if _, err := svc.GetItems(context.Background(), models.CatalogueParams{}); err != nil {
+		t.Fatalf("got unexpected error: %v", err)
+	}
+	if svc.skinportClient.CircuitState() != circuitbreaker.StateOpen {
+		t.Fatal("expected the circuit breaker to open")
+	}
+
+	f.failWith(0)
+	calls := f.calls.Load()
+	svc.cache.Delete(cacheKey)
+
+	stale, err := svc.GetItems(context.Background(), models.CatalogueParams{})
+	if err != nil {
+		t.Fatalf("got unexpected error: %v", err)
+	}
+	if !stale.Stale {
+		t.Error("expected stale catalogue while the breaker is open")
+	}
+	if got := f.calls.Load(); got != calls {
+		t.Errorf("got %d skinport calls while the breaker is open, want none", got-calls)
+	}
+	if health := svc.Health(); health.Status != models.HealthStatusDegraded || health.Skinport.Circuit != "open" {
+		t.Errorf("got health %+v, want degraded with open circuit", health)
+	}
+}
+
+func TestService_GetItems_Coalescing(t *testing.T) {
+	price := 10.00
+	f := newFakeSkinport(t,
+		[]skinport.Item{{MarketHashName: "Item", Currency: "EUR", MinPrice: &price}},
+		[]skinport.Item{{MarketHashName: "Item", Currency: "EUR", MinPrice: &price}},
+	)
+	svc := newTestService(t, f, 300)
+
+	const goroutines = 50
+
+	var wg sync.WaitGroup
+	wg.Add(goroutines)
+	for range goroutines {
+		go func() {
+			defer wg.Done()
+			if _, err := svc.GetItems(context.Background(), models.CatalogueParams{}); err != nil {
+				t.Errorf("got unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if calls := f.calls.Load(); calls != 2 {
+		t.Errorf("expected one tradable and one non-tradable skinport call, got %d", calls)
+	}
+}
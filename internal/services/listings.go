@@ -0,0 +1,75 @@
+package services
+
+import (
+	"backend-test-golang/internal/models"
+	"backend-test-golang/pkg/skinport/salefeed"
+	"log"
+	"strings"
+)
+
+// saleFeedBuffer is how many sale feed events may wait for the price streams before new ones are dropped.
+const saleFeedBuffer = 64
+
+// forwardListings passes the listings of the sale feed to the price streams until the feed stops.
+func (s *Service) forwardListings(sub *salefeed.Subscription) {
+	params, err := s.catalogueParams(models.CatalogueParams{})
+	if err != nil {
+		log.Printf("[ERROR] forwardListings: %v\n", err)
+		sub.Close()
+		return
+	}
+
+	for event := range sub.Events() {
+		s.publishListings(params, event)
+	}
+}
+
+// publishListings sends the listings of event that undercut the min price of their side in the cached catalogue
+// to the price streams. The catalogue itself changes only on refresh, so its versions and ETags stay consistent.
+func (s *Service) publishListings(params models.CatalogueParams, event salefeed.Event) {
+	if event.Type != salefeed.EventListed {
+		return
+	}
+
+	// Without a cached catalogue there is no min price to undercut.
+	catalogue, err := getCached[*Catalogue](s, catalogueCacheKey(skinportItemsCacheKey, params))
+	if err != nil {
+		return
+	}
+
+	var changes []models.ItemChange
+	for _, sale := range event.Sales {
+		if sale.AppID != params.AppID || !strings.EqualFold(sale.Currency, params.Currency) {
+			continue
+		}
+
+		item, ok := catalogue.Item(sale.MarketHashName)
+		if !ok {
+			continue
+		}
+
+		side, current := models.SideNonTradable, item.MinPriceNonTradable
+		if sale.Tradable() {
+			side, current = models.SideTradable, item.MinPriceTradable
+		}
+
+		if current == nil || sale.Price() >= *current {
+			continue
+		}
+
+		oldPrice, newPrice := *current, sale.Price()
+
+		changes = append(changes, models.ItemChange{
+			Version:        catalogue.Version,
+			Type:           models.ItemChangePriceDown,
+			MarketHashName: item.MarketHashName,
+			Side:           side,
+			OldPrice:       &oldPrice,
+			NewPrice:       &newPrice,
+		})
+	}
+
+	if len(changes) > 0 {
+		s.stream.publishListings(catalogue, changes)
+	}
+}
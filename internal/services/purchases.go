@@ -0,0 +1,137 @@
+package services
+
+import (
+	"backend-test-golang/internal/models"
+	errs "backend-test-golang/pkg/errors"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// Purchase buys an item at its cached catalogue min price. A repeated request with the same idempotency key
+// returns the original order without charging again, a different request with it fails with ErrIdempotencyKeyUsed.
+func (s *Service) Purchase(ctx context.Context, in models.PurchaseRequest) (*models.Order, error) {
+	if err := in.Validate(); err != nil {
+		err = errors.Join(errs.ErrValidationFailed, err)
+		log.Printf("validation error in purchase: %v", err)
+		return nil, err
+	}
+
+	params, err := s.purchaseParams(in.CatalogueParams)
+	if err != nil {
+		log.Printf("validation error in purchase: %v", err)
+		return nil, err
+	}
+
+	order, err := s.repo.GetOrderByIdempotencyKey(ctx, in.IdempotencyKey)
+	if err == nil {
+		return idempotentOrder(order, in, params)
+	}
+	if !errors.Is(err, errs.ErrNotFound) {
+		log.Printf("failed to get order: %v", err)
+		return nil, err
+	}
+
+	// The key may already belong to a transaction that did not pay for an order, e.g. a plain withdrawal.
+	if _, err = s.repo.GetTransactionByIdempotencyKey(ctx, in.IdempotencyKey); err == nil {
+		return nil, errs.ErrIdempotencyKeyUsed
+	}
+
+	item, snapshot, err := s.GetItem(ctx, params, in.MarketHashName)
+	if err != nil {
+		return nil, err
+	}
+
+	price, err := purchasePrice(item, snapshot, *in.Tradable, in.MaxPrice)
+	if err != nil {
+		log.Printf("purchase of %q rejected: %v", in.MarketHashName, err)
+		return nil, err
+	}
+
+	order, err = s.repo.CreateOrder(ctx, in.IdempotencyKey, models.Order{
+		UserID:         in.UserID,
+		AppID:          snapshot.Params.AppID,
+		Currency:       snapshot.Params.Currency,
+		MarketHashName: item.MarketHashName,
+		Tradable:       *in.Tradable,
+		Price:          price,
+		MaxPrice:       in.MaxPrice,
+	})
+	if errors.Is(err, errs.ErrIdempotencyKeyUsed) {
+		// A concurrent request with the same key won, answer with its order.
+		// The key may also have been taken by a transaction that is not a purchase, then the error stands.
+		if order, getErr := s.repo.GetOrderByIdempotencyKey(ctx, in.IdempotencyKey); getErr == nil {
+			return idempotentOrder(order, in, params)
+		}
+	}
+	if err != nil {
+		log.Printf("failed to create order: %v", err)
+		return nil, err
+	}
+
+	return order, nil
+}
+
+// idempotentOrder returns the order already made with the request idempotency key, unless it was made
+// by another user or for another item, side or max price.
+func idempotentOrder(order *models.Order, in models.PurchaseRequest, params models.CatalogueParams) (*models.Order, error) {
+	if order.UserID != in.UserID ||
+		order.AppID != params.AppID ||
+		order.Currency != params.Currency ||
+		order.MarketHashName != in.MarketHashName ||
+		order.Tradable != *in.Tradable ||
+		!order.MaxPrice.Equal(in.MaxPrice) {
+		return nil, fmt.Errorf("key of order(%d) of user(%d) for %q: %w", order.ID, order.UserID, order.MarketHashName, errs.ErrIdempotencyKeyUsed)
+	}
+
+	return order, nil
+}
+
+// purchaseParams resolves the catalogue of a purchase. Only the default catalogue can be bought from:
+// its currency is the one of the user balance, which has none of its own.
+func (s *Service) purchaseParams(params models.CatalogueParams) (models.CatalogueParams, error) {
+	params, err := s.catalogueParams(params)
+	if err != nil {
+		return params, err
+	}
+
+	if params.AppID != s.defaultAppID || params.Currency != strings.ToUpper(s.defaultCurrency) {
+		return params, errors.Join(errs.ErrValidationFailed, fmt.Errorf(
+			"purchases are only available for app_id %d in %s, the balance currency", s.defaultAppID, strings.ToUpper(s.defaultCurrency)))
+	}
+
+	return params, nil
+}
+
+// purchasePrice is the min price of the chosen side of item rounded to cents, as long as it is positive and within maxPrice.
+// Prices of a stale or partial catalogue are not charged: the first may be outdated, the second may miss the cheaper side.
+func purchasePrice(item *models.ItemResponse, snapshot models.CatalogueSnapshot, tradable bool, maxPrice decimal.Decimal) (decimal.Decimal, error) {
+	if snapshot.Stale || snapshot.Partial {
+		return decimal.Zero, fmt.Errorf("catalogue version %d is stale(%t) or partial(%t): %w",
+			snapshot.Version, snapshot.Stale, snapshot.Partial, errs.ErrPriceNotCurrent)
+	}
+
+	side, price := models.SideNonTradable, item.MinPriceNonTradable
+	if tradable {
+		side, price = models.SideTradable, item.MinPriceTradable
+	}
+
+	if price == nil {
+		return decimal.Zero, fmt.Errorf("item %q has no %s listings: %w", item.MarketHashName, side, errs.ErrItemUnavailable)
+	}
+
+	amount := decimal.NewFromFloat(*price).Round(2)
+	if !amount.IsPositive() {
+		return decimal.Zero, fmt.Errorf("item %q has %s price %s %s: %w", item.MarketHashName, side, amount, item.Currency, errs.ErrItemUnavailable)
+	}
+	if amount.GreaterThan(maxPrice) {
+		return decimal.Zero, fmt.Errorf("current %s price %s %s is above max_price %s: %w",
+			side, amount, item.Currency, maxPrice, errs.ErrPriceAboveLimit)
+	}
+
+	return amount, nil
+}
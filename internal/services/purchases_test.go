@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"backend-test-golang/internal/models"
+	errs "backend-test-golang/pkg/errors"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestPurchasePrice(t *testing.T) {
+	price := func(p float64) *float64 { return &p }
+
+	item := &models.ItemResponse{
+		MarketHashName:      "AK-47 | Redline (Field-Tested)",
+		Currency:            "EUR",
+		MinPriceTradable:    price(25.99),
+		MinPriceNonTradable: nil,
+	}
+	// 0.004 rounds to zero cents.
+	free := &models.ItemResponse{MarketHashName: "Sticker | Free", Currency: "EUR", MinPriceTradable: price(0.004)}
+
+	tests := []struct {
+		name     string
+		item     *models.ItemResponse
+		snapshot models.CatalogueSnapshot
+		tradable bool
+		maxPrice string
+		want     string
+		wantErr  error
+	}{
+		{name: "below the guard", tradable: true, maxPrice: "30", want: "25.99"},
+		{name: "equal to the guard", tradable: true, maxPrice: "25.99", want: "25.99"},
+		{name: "above the guard", tradable: true, maxPrice: "25.98", wantErr: errs.ErrPriceAboveLimit},
+		{name: "side without listings", tradable: false, maxPrice: "100", wantErr: errs.ErrItemUnavailable},
+		{name: "stale catalogue", snapshot: models.CatalogueSnapshot{Stale: true}, tradable: true, maxPrice: "30", wantErr: errs.ErrPriceNotCurrent},
+		{name: "partial catalogue", snapshot: models.CatalogueSnapshot{Partial: true}, tradable: true, maxPrice: "30", wantErr: errs.ErrPriceNotCurrent},
+		{name: "price rounded to zero", item: free, tradable: true, maxPrice: "100", wantErr: errs.ErrItemUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.item == nil {
+				tt.item = item
+			}
+
+			got, err := purchasePrice(tt.item, tt.snapshot, tt.tradable, decimal.RequireFromString(tt.maxPrice))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("got error %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !got.Equal(decimal.RequireFromString(tt.want)) {
+				t.Errorf("got price %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestService_Purchase_OtherCatalogue(t *testing.T) {
+	f := newFakeSkinport(t, nil, nil)
+	svc := newTestService(t, f, 300)
+	tradable := true
+
+	tests := []struct {
+		name   string
+		params models.CatalogueParams
+	}{
+		{name: "other currency", params: models.CatalogueParams{Currency: "USD"}},
+		{name: "other app", params: models.CatalogueParams{AppID: 570}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.Purchase(context.Background(), models.PurchaseRequest{
+				CatalogueParams: tt.params,
+				IdempotencyKey:  "5f0c7a3e-6d2b-4f3a-9c1e-2b7d8e9f0a1b",
+				UserID:          1,
+				MarketHashName:  "AK-47 | Redline (Field-Tested)",
+				Tradable:        &tradable,
+				MaxPrice:        decimal.NewFromInt(100),
+			})
+			if !errors.Is(err, errs.ErrValidationFailed) {
+				t.Fatalf("got error %v, want %v", err, errs.ErrValidationFailed)
+			}
+			if calls := f.calls.Load(); calls != 0 {
+				t.Errorf("got %d skinport calls, want none", calls)
+			}
+		})
+	}
+}
+
+func TestIdempotentOrder(t *testing.T) {
+	tradable, nonTradable := true, false
+	params := models.CatalogueParams{AppID: 730, Currency: "EUR"}
+	order := &models.Order{
+		ID:             1,
+		UserID:         7,
+		AppID:          730,
+		Currency:       "EUR",
+		MarketHashName: "AK-47 | Redline (Field-Tested)",
+		Tradable:       true,
+		Price:          decimal.RequireFromString("25.99"),
+		MaxPrice:       decimal.NewFromInt(30),
+	}
+	request := func(modify func(r *models.PurchaseRequest)) models.PurchaseRequest {
+		r := models.PurchaseRequest{
+			UserID:         7,
+			MarketHashName: "AK-47 | Redline (Field-Tested)",
+			Tradable:       &tradable,
+			MaxPrice:       decimal.RequireFromString("30.00"),
+		}
+		modify(&r)
+		return r
+	}
+
+	tests := []struct {
+		name    string
+		req     models.PurchaseRequest
+		wantErr bool
+	}{
+		{name: "same request", req: request(func(*models.PurchaseRequest) {})},
+		{name: "other user", req: request(func(r *models.PurchaseRequest) { r.UserID = 8 }), wantErr: true},
+		{name: "other item", req: request(func(r *models.PurchaseRequest) { r.MarketHashName = "AWP | Asiimov (Field-Tested)" }), wantErr: true},
+		{name: "other side", req: request(func(r *models.PurchaseRequest) { r.Tradable = &nonTradable }), wantErr: true},
+		{name: "other max price", req: request(func(r *models.PurchaseRequest) { r.MaxPrice = decimal.NewFromInt(31) }), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := idempotentOrder(order, tt.req, params)
+			if tt.wantErr {
+				if !errors.Is(err, errs.ErrIdempotencyKeyUsed) {
+					t.Errorf("got error %v, want %v", err, errs.ErrIdempotencyKeyUsed)
+				}
+				return
+			}
+
+			if err != nil || got != order {
+				t.Errorf("got %v, %v, want the original order", got, err)
+			}
+		})
+	}
+}
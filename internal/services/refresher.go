@@ -0,0 +1,104 @@
+package services
+
+import (
+	"backend-test-golang/internal/models"
+	"backend-test-golang/pkg/circuitbreaker"
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// catalogueRefreshCost is the number of Skinport calls one catalogue refresh spends (tradable + non-tradable).
+const catalogueRefreshCost = 2
+
+// Refresher reloads the default catalogue in the background shortly before its cache entry expires,
+// so that requests keep getting the previous snapshot instead of waiting for Skinport.
+// Partial catalogues are reloaded as soon as the rate limit allows.
+type Refresher struct {
+	svc      *Service
+	interval time.Duration
+	ahead    time.Duration
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+	syncOnce sync.Once
+}
+
+// NewRefresher creates a refresher that checks the cached catalogue every interval
+// and reloads it when less than ahead is left until expiry.
+func NewRefresher(svc *Service, interval, ahead time.Duration) *Refresher {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Refresher{
+		svc:      svc,
+		interval: interval,
+		ahead:    ahead,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+func (r *Refresher) Start() {
+	r.wg.Add(1)
+	go r.run()
+}
+
+// Stop cancels an in-flight refresh and waits for the worker to exit.
+func (r *Refresher) Stop() {
+	r.syncOnce.Do(func() {
+		r.cancel()
+		r.wg.Wait()
+		log.Println("catalogue refresher stopped")
+	})
+}
+
+func (r *Refresher) run() {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	r.refresh()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.refresh()
+		case <-r.ctx.Done():
+			return
+		}
+	}
+}
+
+func (r *Refresher) refresh() {
+	params, err := r.svc.catalogueParams(models.CatalogueParams{})
+	if err != nil {
+		log.Printf("[ERROR] Refresher: invalid default catalogue params: %v\n", err)
+		return
+	}
+
+	catalogue, err := getCached[*Catalogue](r.svc, catalogueCacheKey(skinportItemsCacheKey, params))
+	if err == nil && !catalogue.Partial && time.Until(catalogue.ExpiresAt) > r.ahead {
+		return
+	}
+
+	// Transitions of the breaker are logged by itself; once the cool-down is over the refresh is the probe call.
+	if r.svc.skinportClient.CircuitState() == circuitbreaker.StateOpen {
+		return
+	}
+
+	if remaining := r.svc.skinportClient.RemainingRequests(); remaining < catalogueRefreshCost {
+		log.Printf("[WARN] Refresher: skipping refresh, %d skinport requests left in the rate limit window\n", remaining)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.ctx, 60*time.Second)
+	defer cancel()
+
+	if _, err = r.svc.refreshItems(ctx, params); err != nil {
+		log.Printf("[ERROR] Refresher: failed to refresh catalogue: %v\n", err)
+		return
+	}
+
+	log.Printf("catalogue %d/%s refreshed\n", params.AppID, params.Currency)
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"backend-test-golang/internal/models"
+	"backend-test-golang/pkg/skinport"
+)
+
+func TestRefresher(t *testing.T) {
+	price := 10.00
+	f := newFakeSkinport(t,
+		[]skinport.Item{{MarketHashName: "Item", Currency: "EUR", MinPrice: &price}},
+		[]skinport.Item{{MarketHashName: "Item", Currency: "EUR", MinPrice: &price}},
+	)
+
+	t.Run("warms up an empty cache", func(t *testing.T) {
+		svc := newTestService(t, f, 300)
+		f.calls.Store(0)
+
+		r := NewRefresher(svc, time.Hour, time.Minute)
+		r.Start()
+		defer r.Stop()
+
+		cacheKey := catalogueCacheKey(skinportItemsCacheKey, models.CatalogueParams{AppID: 730, Currency: "EUR"})
+		waitFor(t, 2*time.Second, func() bool {
+			_, err := svc.cache.Get(cacheKey)
+			return err == nil
+		})
+
+		catalogue, err := svc.GetItems(context.Background(), models.CatalogueParams{})
+		if err != nil {
+			t.Fatalf("got unexpected error: %v", err)
+		}
+		if len(catalogue.Items) != 1 {
+			t.Errorf("expected 1 item, got %d", len(catalogue.Items))
+		}
+		if f.calls.Load() != 2 {
+			t.Errorf("GetItems should be served from cache, got %d skinport calls", f.calls.Load())
+		}
+	})
+
+	t.Run("refreshes before expiry", func(t *testing.T) {
+		svc := newTestService(t, f, 1)
+
+		old, err := svc.GetItems(context.Background(), models.CatalogueParams{})
+		if err != nil {
+			t.Fatalf("got unexpected error: %v", err)
+		}
+		f.calls.Store(0)
+
+		r := NewRefresher(svc, 50*time.Millisecond, 900*time.Millisecond)
+		r.Start()
+		defer r.Stop()
+
+		cacheKey := catalogueCacheKey(skinportItemsCacheKey, old.Params)
+		waitFor(t, 2*time.Second, func() bool {
+			current, err := getCached[*Catalogue](svc, cacheKey)
+			return err == nil && current != old
+		})
+	})
+
+	t.Run("respects the rate limit budget", func(t *testing.T) {
+		svc := newTestService(t, f, 300)
+		for svc.skinportClient.RemainingRequests() > 1 {
+			if _, err := svc.skinportClient.GetItems(context.Background(), 730, "EUR", true); err != nil {
+				t.Fatalf("got unexpected error: %v", err)
+			}
+		}
+		f.calls.Store(0)
+
+		r := NewRefresher(svc, time.Hour, time.Minute)
+		r.Start()
+		r.Stop()
+
+		if f.calls.Load() != 0 {
+			t.Errorf("refresher should not call skinport without budget, got %d calls", f.calls.Load())
+		}
+	})
+}
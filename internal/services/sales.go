@@ -0,0 +1,149 @@
+package services
+
+import (
+	"backend-test-golang/internal/models"
+	errs "backend-test-golang/pkg/errors"
+	"backend-test-golang/pkg/skinport"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+)
+
+const (
+	skinportSalesHistoryCacheKey = "skinport:sales:history"
+	skinportOutOfStockCacheKey   = "skinport:sales:out-of-stock"
+)
+
+// GetSalesHistory returns the sales history of the requested items. The history of the whole app is fetched
+// and cached once per app/currency, so asking for different items does not spend the Skinport rate limit.
+// Concurrent cache misses share one fetch, as in GetItems.
+func (s *Service) GetSalesHistory(ctx context.Context, in models.SalesHistoryRequest) ([]*models.SalesHistory, error) {
+	if err := in.Validate(); err != nil {
+		err = errors.Join(errs.ErrValidationFailed, err)
+		log.Printf("validation error in get sales history: %v", err)
+		return nil, err
+	}
+
+	params, err := s.catalogueParams(in.CatalogueParams)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheKey := catalogueCacheKey(skinportSalesHistoryCacheKey, params)
+
+	entry, err := s.cache.GetOrLoad(ctx, cacheKey, s.defaultCacheTTL, func(ctx context.Context) (any, error) {
+		return s.fetchSalesHistory(ctx, params)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	history, ok := entry.([]*models.SalesHistory)
+	if !ok {
+		return nil, fmt.Errorf("failed to get sales history: %w", errs.ErrInvalidCacheEntry)
+	}
+
+	if len(in.MarketHashNames) == 0 {
+		return history, nil
+	}
+
+	wanted := make(map[string]struct{}, len(in.MarketHashNames))
+	for _, name := range in.MarketHashNames {
+		wanted[name] = struct{}{}
+	}
+
+	result := make([]*models.SalesHistory, 0, len(in.MarketHashNames))
+	for _, item := range history {
+		if _, ok := wanted[item.MarketHashName]; ok {
+			result = append(result, item)
+		}
+	}
+
+	return result, nil
+}
+
+// GetOutOfStock returns the cached out of stock items of the catalogue, concurrent cache misses share one fetch.
+func (s *Service) GetOutOfStock(ctx context.Context, params models.CatalogueParams) ([]*models.OutOfStockItem, error) {
+	params, err := s.catalogueParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheKey := catalogueCacheKey(skinportOutOfStockCacheKey, params)
+
+	entry, err := s.cache.GetOrLoad(ctx, cacheKey, s.defaultCacheTTL, func(ctx context.Context) (any, error) {
+		return s.fetchOutOfStock(ctx, params)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	items, ok := entry.([]*models.OutOfStockItem)
+	if !ok {
+		return nil, fmt.Errorf("failed to get out of stock items: %w", errs.ErrInvalidCacheEntry)
+	}
+
+	return items, nil
+}
+
+func (s *Service) fetchSalesHistory(ctx context.Context, params models.CatalogueParams) ([]*models.SalesHistory, error) {
+	sales, err := s.skinportClient.GetSalesHistory(ctx, params.AppID, params.Currency)
+	if err != nil {
+		log.Printf("[ERROR] GetSalesHistory: failed to get sales history: %v\n", err)
+		return nil, err
+	}
+
+	history := make([]*models.SalesHistory, 0, len(sales))
+	for _, sale := range sales {
+		history = append(history, newSalesHistory(sale))
+	}
+
+	return history, nil
+}
+
+func (s *Service) fetchOutOfStock(ctx context.Context, params models.CatalogueParams) ([]*models.OutOfStockItem, error) {
+	outOfStock, err := s.skinportClient.GetOutOfStock(ctx, params.AppID, params.Currency)
+	if err != nil {
+		log.Printf("[ERROR] GetOutOfStock: failed to get out of stock items: %v\n", err)
+		return nil, err
+	}
+
+	items := make([]*models.OutOfStockItem, 0, len(outOfStock))
+	for _, item := range outOfStock {
+		items = append(items, &models.OutOfStockItem{
+			MarketHashName: item.MarketHashName,
+			Version:        item.Version,
+			Currency:       item.Currency,
+			SuggestedPrice: item.SuggestedPrice,
+			AvgSalePrice:   item.AvgSalePrice,
+			SalesLast90d:   item.SalesLast90d,
+		})
+	}
+
+	return items, nil
+}
+
+func newSalesHistory(sale skinport.SalesHistory) *models.SalesHistory {
+	return &models.SalesHistory{
+		MarketHashName: sale.MarketHashName,
+		Version:        sale.Version,
+		Currency:       sale.Currency,
+		ItemPage:       sale.ItemPage,
+		MarketPage:     sale.MarketPage,
+		Last24Hours:    newSalesStats(sale.Last24Hours),
+		Last7Days:      newSalesStats(sale.Last7Days),
+		Last30Days:     newSalesStats(sale.Last30Days),
+		Last90Days:     newSalesStats(sale.Last90Days),
+	}
+}
+
+func newSalesStats(stats skinport.SalesStats) *models.SalesStats {
+	return &models.SalesStats{
+		Min:    stats.Min,
+		Max:    stats.Max,
+		Avg:    stats.Avg,
+		Median: stats.Median,
+		Volume: stats.Volume,
+	}
+}
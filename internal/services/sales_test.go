@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"backend-test-golang/internal/models"
+	"backend-test-golang/pkg/skinport"
+)
+
+func TestService_Sales_Coalescing(t *testing.T) {
+	price := 10.00
+	items := []skinport.Item{{MarketHashName: "Item", Currency: "EUR", MinPrice: &price}}
+
+	tests := []struct {
+		name string
+		get  func(svc *Service) error
+	}{
+		{
+			name: "sales history",
+			get: func(svc *Service) error {
+				_, err := svc.GetSalesHistory(context.Background(), models.SalesHistoryRequest{})
+				return err
+			},
+		},
+		{
+			name: "out of stock",
+			get: func(svc *Service) error {
+				_, err := svc.GetOutOfStock(context.Background(), models.CatalogueParams{})
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeSkinport(t, items, items)
+			// Both endpoints have no tradable param, so they are answered by the non-tradable side.
+			f.nonTradable.delay.Store(int64(100 * time.Millisecond))
+			svc := newTestService(t, f, 300)
+
+			const goroutines = 50
+
+			var wg sync.WaitGroup
+			wg.Add(goroutines)
+			for range goroutines {
+				go func() {
+					defer wg.Done()
+					if err := tt.get(svc); err != nil {
+						t.Errorf("got unexpected error: %v", err)
+					}
+				}()
+			}
+			wg.Wait()
+
+			if err := tt.get(svc); err != nil {
+				t.Errorf("got unexpected error: %v", err)
+			}
+
+			if calls := f.calls.Load(); calls != 1 {
+				t.Errorf("expected one skinport call, got %d", calls)
+			}
+		})
+	}
+}
@@ -1,24 +1,92 @@
 package services
 
 import (
+	"backend-test-golang/internal/config"
+	"backend-test-golang/internal/models"
 	"backend-test-golang/internal/repository"
 	"backend-test-golang/pkg/cache"
+	errs "backend-test-golang/pkg/errors"
 	"backend-test-golang/pkg/skinport"
+	"backend-test-golang/pkg/skinport/salefeed"
+	"backend-test-golang/pkg/webhook"
+	"fmt"
+	"sync"
 	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// PartialPolicyFail fails the catalogue fetch when either the tradable or the non-tradable side fails.
+	PartialPolicyFail = "fail"
+	// PartialPolicyPartial returns the side that was fetched with a warning.
+	PartialPolicyPartial = "partial"
 )
 
 type Service struct {
-	defaultCacheTTL time.Duration
-	cache           *cache.MemCache
-	skinportClient  *skinport.Client
-	repo            *repository.Repository
+	defaultCacheTTL       time.Duration
+	maxStale              time.Duration
+	allowPartialCatalogue bool
+	defaultAppID          int
+	defaultCurrency       string
+	snapshotsEnabled      bool
+	snapshotRetention     time.Duration
+	alertsEnabled         bool
+	webhookClient         *webhook.Client
+	changes               *changeFeed
+	stream                *priceBroker
+	wg                    sync.WaitGroup
+	cache                 *cache.MemCache
+	skinportClient        *skinport.Client
+	saleFeed              *salefeed.Client
+	depositMaxAmount      decimal.Decimal
+	repo                  *repository.Repository
 }
 
-func New(cacheTTL int, cache *cache.MemCache, skinportClient *skinport.Client, repo *repository.Repository) *Service {
+func New(conf *config.Config, cache *cache.MemCache, skinportClient *skinport.Client, repo *repository.Repository) *Service {
+	webhookClient := webhook.NewClient(
+		time.Duration(conf.WebhookTimeoutSeconds)*time.Second,
+		conf.WebhookMaxAttempts,
+		time.Duration(conf.WebhookBaseBackoffMs)*time.Millisecond,
+	)
+
 	return &Service{
-		defaultCacheTTL: time.Duration(cacheTTL) * time.Second,
-		cache:           cache,
-		skinportClient:  skinportClient,
-		repo:            repo,
+		defaultCacheTTL:       time.Duration(conf.CacheTTLSeconds) * time.Second,
+		maxStale:              time.Duration(conf.CacheMaxStaleSeconds) * time.Second,
+		allowPartialCatalogue: conf.SkinportPartialPolicy == PartialPolicyPartial,
+		defaultAppID:          conf.SkinportAppID,
+		defaultCurrency:       conf.SkinportCurrency,
+		snapshotsEnabled:      conf.SnapshotsEnabled,
+		snapshotRetention:     time.Duration(conf.SnapshotRetentionHours) * time.Hour,
+		alertsEnabled:         conf.AlertsEnabled,
+		webhookClient:         webhookClient,
+		changes:               newChangeFeed(conf.ChangesHistoryVersions),
+		stream:                newPriceBroker(),
+		cache:                 cache,
+		skinportClient:        skinportClient,
+		depositMaxAmount:      decimal.NewFromFloat(conf.DepositMaxAmount).Round(2),
+		repo:                  repo,
 	}
 }
+
+// catalogueCacheKey makes cache keys per app/currency, e.g. skinport:items:730:EUR.
+func catalogueCacheKey(prefix string, params models.CatalogueParams) string {
+	return fmt.Sprintf("%s:%d:%s", prefix, params.AppID, params.Currency)
+}
+
+// getCached reads a cache entry and checks it has the expected type.
+func getCached[T any](s *Service, key string) (T, error) {
+	var zero T
+
+	entry, err := s.cache.Get(key)
+	if err != nil {
+		return zero, err
+	}
+
+	result, ok := entry.(T)
+	if !ok {
+		return zero, fmt.Errorf("failed to get %s: %w", key, errs.ErrInvalidCacheEntry)
+	}
+
+	return result, nil
+}
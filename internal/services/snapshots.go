@@ -0,0 +1,51 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// snapshotTimeout bounds saving one catalogue, about 20k rows per side.
+const snapshotTimeout = 60 * time.Second
+
+// saveSnapshot persists the prices of a complete catalogue in the background, so that fetching
+// is not slowed down by the database, and then removes snapshots older than the retention.
+func (s *Service) saveSnapshot(catalogue *Catalogue) {
+	if !s.snapshotsEnabled {
+		return
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ctx, cancel := context.WithTimeout(context.Background(), snapshotTimeout)
+		defer cancel()
+
+		inserted, err := s.repo.InsertPriceSnapshots(ctx, catalogue.Params, catalogue.FetchedAt, catalogue.Items)
+		if err != nil {
+			log.Printf("[ERROR] saveSnapshot: failed to save catalogue %d/%s: %v\n", catalogue.Params.AppID, catalogue.Params.Currency, err)
+			return
+		}
+		log.Printf("saved %d price snapshots of catalogue %d/%s\n", inserted, catalogue.Params.AppID, catalogue.Params.Currency)
+
+		if s.snapshotRetention <= 0 {
+			return
+		}
+
+		deleted, err := s.repo.DeletePriceSnapshotsBefore(ctx, time.Now().Add(-s.snapshotRetention))
+		if err != nil {
+			log.Printf("[ERROR] saveSnapshot: failed to delete old snapshots: %v\n", err)
+			return
+		}
+		if deleted > 0 {
+			log.Printf("deleted %d price snapshots older than %v\n", deleted, s.snapshotRetention)
+		}
+	}()
+}
+
+// Close waits for the snapshots being saved and the alerts being delivered.
+func (s *Service) Close() {
+	s.wg.Wait()
+}
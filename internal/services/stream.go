@@ -0,0 +1,204 @@
+package services
+
+import (
+	"backend-test-golang/internal/models"
+	errs "backend-test-golang/pkg/errors"
+	"context"
+	"errors"
+	"log"
+	"sync"
+)
+
+// priceSubscriptionBuffer is how many events a subscriber may lag behind before it is dropped.
+const priceSubscriptionBuffer = 16
+
+// ErrStreamsClosed is returned by SubscribePrices after CloseStreams.
+var ErrStreamsClosed = errors.New("price streams are closed")
+
+// PriceSubscription receives the events of one price stream until it is closed.
+type PriceSubscription struct {
+	filter models.PriceStreamFilter
+	events chan models.PriceEvent
+	broker *priceBroker
+	once   sync.Once
+}
+
+// Events is closed when the subscription is closed, the subscriber lagged behind or the server shuts down.
+func (s *PriceSubscription) Events() <-chan models.PriceEvent {
+	return s.events
+}
+
+func (s *PriceSubscription) Close() {
+	s.broker.unsubscribe(s)
+}
+
+// priceBroker fans catalogue refreshes out to the price streams. Publishing never blocks the refresh:
+// a subscriber whose buffer is full is dropped and has to reconnect.
+type priceBroker struct {
+	mu     sync.Mutex
+	subs   map[*PriceSubscription]struct{}
+	closed bool
+}
+
+func newPriceBroker() *priceBroker {
+	return &priceBroker{subs: make(map[*PriceSubscription]struct{})}
+}
+
+func (b *priceBroker) subscribe(filter models.PriceStreamFilter) (*PriceSubscription, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, ErrStreamsClosed
+	}
+
+	sub := &PriceSubscription{
+		filter: filter,
+		events: make(chan models.PriceEvent, priceSubscriptionBuffer),
+		broker: b,
+	}
+	b.subs[sub] = struct{}{}
+
+	return sub, nil
+}
+
+func (b *priceBroker) unsubscribe(sub *PriceSubscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.remove(sub)
+}
+
+// remove must be called with b.mu held.
+func (b *priceBroker) remove(sub *PriceSubscription) {
+	delete(b.subs, sub)
+	sub.once.Do(func() { close(sub.events) })
+}
+
+// publish sends a refresh event of catalogue to its subscribers, followed by the price changes
+// matching their filters.
+func (b *priceBroker) publish(catalogue *Catalogue, changes []models.ItemChange) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	refresh := refreshEvent(catalogue)
+
+	for sub := range b.subs {
+		if sub.filter.CatalogueParams != catalogue.Params {
+			continue
+		}
+
+		events := []models.PriceEvent{refresh}
+		if matched := matchChanges(sub.filter, changes); len(matched) > 0 {
+			prices := refresh
+			prices.Type = models.PriceEventPrices
+			prices.Changes = matched
+			events = append(events, prices)
+		}
+
+		b.send(sub, events...)
+	}
+}
+
+// publishListings sends the sale feed listings undercutting catalogue to the subscribers whose filters match them.
+func (b *priceBroker) publishListings(catalogue *Catalogue, changes []models.ItemChange) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sub := range b.subs {
+		if sub.filter.CatalogueParams != catalogue.Params {
+			continue
+		}
+
+		matched := matchChanges(sub.filter, changes)
+		if len(matched) == 0 {
+			continue
+		}
+
+		listing := refreshEvent(catalogue)
+		listing.Type = models.PriceEventListing
+		listing.Changes = matched
+		b.send(sub, listing)
+	}
+}
+
+// send must be called with b.mu held. A subscriber without room for all events is dropped.
+func (b *priceBroker) send(sub *PriceSubscription, events ...models.PriceEvent) {
+	for _, event := range events {
+		select {
+		case sub.events <- event:
+			continue
+		default:
+		}
+
+		log.Printf("[WARN] price stream subscriber is too slow, dropping it\n")
+		b.remove(sub)
+		return
+	}
+}
+
+func matchChanges(filter models.PriceStreamFilter, changes []models.ItemChange) []models.ItemChange {
+	var matched []models.ItemChange
+	for _, change := range changes {
+		if filter.Match(change) {
+			matched = append(matched, change)
+		}
+	}
+	return matched
+}
+
+// close ends all streams and refuses new ones.
+func (b *priceBroker) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for sub := range b.subs {
+		b.remove(sub)
+	}
+}
+
+// SubscribePrices opens a price stream and returns the catalogue version it starts from as the first refresh event.
+// The subscriber is registered before the catalogue is loaded, so that a refresh in between is not lost;
+// it may then be delivered with a version the first event already has.
+func (s *Service) SubscribePrices(ctx context.Context, filter models.PriceStreamFilter) (*PriceSubscription, models.PriceEvent, error) {
+	if err := filter.Validate(); err != nil {
+		err = errors.Join(errs.ErrValidationFailed, err)
+		log.Printf("validation error in subscribe prices: %v", err)
+		return nil, models.PriceEvent{}, err
+	}
+
+	params, err := s.catalogueParams(filter.CatalogueParams)
+	if err != nil {
+		return nil, models.PriceEvent{}, err
+	}
+	filter.CatalogueParams = params
+
+	sub, err := s.stream.subscribe(filter)
+	if err != nil {
+		return nil, models.PriceEvent{}, err
+	}
+
+	catalogue, err := s.GetItems(ctx, params)
+	if err != nil {
+		sub.Close()
+		return nil, models.PriceEvent{}, err
+	}
+
+	return sub, refreshEvent(catalogue), nil
+}
+
+func refreshEvent(catalogue *Catalogue) models.PriceEvent {
+	return models.PriceEvent{
+		Type:      models.PriceEventRefresh,
+		AppID:     catalogue.Params.AppID,
+		Currency:  catalogue.Params.Currency,
+		Version:   catalogue.Version,
+		FetchedAt: catalogue.FetchedAt,
+	}
+}
+
+// CloseStreams ends all price streams, so that server shutdown does not wait for them.
+func (s *Service) CloseStreams() {
+	s.stream.close()
+}
@@ -0,0 +1,179 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"backend-test-golang/internal/models"
+	"backend-test-golang/pkg/skinport"
+	"backend-test-golang/pkg/skinport/salefeed"
+)
+
+func TestPriceBroker(t *testing.T) {
+	price := func(p float64) *float64 { return &p }
+	params := models.CatalogueParams{AppID: 730, Currency: "EUR"}
+
+	catalogue := testChangesCatalogue()
+	catalogue.Version = 2
+	changes := []models.ItemChange{
+		{Version: 2, Type: models.ItemChangePriceDown, MarketHashName: "AK-47 | Redline (Field-Tested)", Side: models.SideTradable, OldPrice: price(10), NewPrice: price(9)},
+		{Version: 2, Type: models.ItemChangePriceUp, MarketHashName: "AWP | Asiimov (Field-Tested)", Side: models.SideTradable, OldPrice: price(50), NewPrice: price(55)},
+		{Version: 2, Type: models.ItemChangeRemoved, MarketHashName: "M4A4 | Howl (Field-Tested)"},
+	}
+
+	t.Run("filtered price changes follow the refresh", func(t *testing.T) {
+		b := newPriceBroker()
+		byName, _ := b.subscribe(models.PriceStreamFilter{CatalogueParams: params, MarketHashNames: []string{"AK-47 | Redline (Field-Tested)"}})
+		byPrice, _ := b.subscribe(models.PriceStreamFilter{CatalogueParams: params, MinPrice: price(50)})
+		otherApp, _ := b.subscribe(models.PriceStreamFilter{CatalogueParams: models.CatalogueParams{AppID: 570, Currency: "EUR"}})
+
+		b.publish(catalogue, changes)
+
+		for name, tt := range map[string]struct {
+			sub  *PriceSubscription
+			want string
+		}{
+			"by name":  {sub: byName, want: "AK-47 | Redline (Field-Tested)"},
+			"by price": {sub: byPrice, want: "AWP | Asiimov (Field-Tested)"},
+		} {
+			refresh := <-tt.sub.Events()
+			if refresh.Type != models.PriceEventRefresh || refresh.Version != 2 {
+				t.Errorf("%s: got first event %+v, want refresh of version 2", name, refresh)
+			}
+
+			prices := <-tt.sub.Events()
+			if prices.Type != models.PriceEventPrices || len(prices.Changes) != 1 || prices.Changes[0].MarketHashName != tt.want {
+				t.Errorf("%s: got event %+v, want price change of %q", name, prices, tt.want)
+			}
+		}
+
+		if len(otherApp.Events()) != 0 {
+			t.Error("subscriber of another catalogue must not get events")
+		}
+	})
+
+	t.Run("no matching changes sends only the refresh", func(t *testing.T) {
+		b := newPriceBroker()
+		sub, _ := b.subscribe(models.PriceStreamFilter{CatalogueParams: params, MarketHashNames: []string{"M4A4 | Howl (Field-Tested)"}})
+
+		b.publish(catalogue, changes)
+
+		if got := len(sub.Events()); got != 1 {
+			t.Errorf("got %d events, want only the refresh", got)
+		}
+	})
+
+	t.Run("slow subscriber is dropped", func(t *testing.T) {
+		b := newPriceBroker()
+		sub, _ := b.subscribe(models.PriceStreamFilter{CatalogueParams: params})
+
+		for range priceSubscriptionBuffer {
+			b.publish(catalogue, nil)
+		}
+		b.publish(catalogue, nil)
+
+		received := 0
+		for range sub.Events() {
+			received++
+		}
+		if received != priceSubscriptionBuffer {
+			t.Errorf("got %d events before the stream closed, want %d", received, priceSubscriptionBuffer)
+		}
+
+		sub.Close() // closing a dropped subscription is a no-op
+	})
+
+	t.Run("close ends streams and refuses new ones", func(t *testing.T) {
+		b := newPriceBroker()
+		sub, _ := b.subscribe(models.PriceStreamFilter{CatalogueParams: params})
+
+		b.close()
+
+		if _, ok := <-sub.Events(); ok {
+			t.Error("events must be closed")
+		}
+		if _, err := b.subscribe(models.PriceStreamFilter{CatalogueParams: params}); err != ErrStreamsClosed {
+			t.Errorf("got error %v, want %v", err, ErrStreamsClosed)
+		}
+	})
+}
+
+func TestService_SubscribePrices(t *testing.T) {
+	t.Run("refresh while the catalogue loads is delivered", func(t *testing.T) {
+		price := 10.0
+		f := newFakeSkinport(t, []skinport.Item{{MarketHashName: "AK-47 | Redline (Field-Tested)", Currency: "EUR", MinPrice: &price}}, nil)
+		f.tradable.delay.Store(int64(200 * time.Millisecond))
+		svc := newTestService(t, f, 300)
+
+		refreshed := testChangesCatalogue()
+		refreshed.Params = models.CatalogueParams{AppID: 730, Currency: "EUR"}
+		refreshed.Version = 42
+		go func() {
+			<-f.tradable.started
+			svc.stream.publish(refreshed, nil)
+		}()
+
+		sub, first, err := svc.SubscribePrices(context.Background(), models.PriceStreamFilter{})
+		if err != nil {
+			t.Fatalf("got unexpected error: %v", err)
+		}
+		defer sub.Close()
+
+		if first.Type != models.PriceEventRefresh {
+			t.Errorf("got first event %+v, want refresh", first)
+		}
+
+		select {
+		case event := <-sub.Events():
+			if event.Version != 42 {
+				t.Errorf("got event %+v, want the refresh of version 42", event)
+			}
+		default:
+			t.Error("refresh published while the catalogue was loading was lost")
+		}
+	})
+}
+
+func TestService_PublishListings(t *testing.T) {
+	price := 10.0
+	f := newFakeSkinport(t, []skinport.Item{
+		{MarketHashName: "AK-47 | Redline (Field-Tested)", Currency: "EUR", MinPrice: &price},
+		{MarketHashName: "AWP | Asiimov (Field-Tested)", Currency: "EUR", MinPrice: &price},
+	}, nil)
+	svc := newTestService(t, f, 300)
+	params := models.CatalogueParams{AppID: 730, Currency: "EUR"}
+
+	sub, _, err := svc.SubscribePrices(context.Background(), models.PriceStreamFilter{MarketHashNames: []string{"AK-47 | Redline (Field-Tested)"}})
+	if err != nil {
+		t.Fatalf("got unexpected error: %v", err)
+	}
+	defer sub.Close()
+	// The subscriber already gets the refresh of the catalogue fetched by SubscribePrices.
+	for len(sub.Events()) > 0 {
+		<-sub.Events()
+	}
+
+	locked := "2024-06-01T00:00:00Z"
+	svc.publishListings(params, salefeed.Event{Type: salefeed.EventListed, Sales: []salefeed.Sale{
+		{AppID: 730, MarketHashName: "AK-47 | Redline (Field-Tested)", SalePrice: 1100, Currency: "EUR"},               // above the min price
+		{AppID: 730, MarketHashName: "AK-47 | Redline (Field-Tested)", SalePrice: 800, Currency: "EUR", Lock: &locked}, // side without listings
+		{AppID: 730, MarketHashName: "AWP | Asiimov (Field-Tested)", SalePrice: 500, Currency: "EUR"},                  // not in the filter
+		{AppID: 730, MarketHashName: "AK-47 | Redline (Field-Tested)", SalePrice: 950, Currency: "EUR"},
+	}})
+	svc.publishListings(params, salefeed.Event{Type: salefeed.EventSold, Sales: []salefeed.Sale{
+		{AppID: 730, MarketHashName: "AK-47 | Redline (Field-Tested)", SalePrice: 100, Currency: "EUR"},
+	}})
+
+	if got := len(sub.Events()); got != 1 {
+		t.Fatalf("got %d events, want one listing", got)
+	}
+
+	event := <-sub.Events()
+	if event.Type != models.PriceEventListing || len(event.Changes) != 1 {
+		t.Fatalf("got event %+v, want a listing with one change", event)
+	}
+	if change := event.Changes[0]; change.Side != models.SideTradable || *change.OldPrice != 10 || *change.NewPrice != 9.5 {
+		t.Errorf("got change %+v, want tradable price down from 10 to 9.5", change)
+	}
+}
@@ -0,0 +1,22 @@
+-- Price snapshots of every complete catalogue fetched from Skinport
+
+CREATE TABLE IF NOT EXISTS item_price_snapshots (
+    id BIGSERIAL PRIMARY KEY,
+    app_id INTEGER NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    market_hash_name VARCHAR NOT NULL,
+    suggested_price NUMERIC(15, 2),
+    min_price_tradable NUMERIC(15, 2),
+    min_price_non_tradable NUMERIC(15, 2),
+    quantity_tradable INTEGER NOT NULL DEFAULT 0,
+    quantity_non_tradable INTEGER NOT NULL DEFAULT 0,
+    fetched_at TIMESTAMP NOT NULL
+);
+
+-- Price history of one item
+CREATE INDEX IF NOT EXISTS idx_item_price_snapshots_item
+    ON item_price_snapshots (app_id, currency, market_hash_name, fetched_at);
+
+-- Retention clean up
+CREATE INDEX IF NOT EXISTS idx_item_price_snapshots_fetched_at
+    ON item_price_snapshots (fetched_at);
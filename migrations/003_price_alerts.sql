@@ -0,0 +1,42 @@
+-- Price alerts of users delivered as webhooks
+
+CREATE TABLE IF NOT EXISTS price_alerts (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    app_id INTEGER NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    market_hash_name VARCHAR NOT NULL,
+    side VARCHAR(16) NOT NULL,             -- tradable | non_tradable
+    condition VARCHAR(8) NOT NULL,         -- below | above
+    threshold NUMERIC(15, 2) NOT NULL,
+    webhook_url VARCHAR NOT NULL,
+    webhook_secret VARCHAR NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    triggered BOOLEAN NOT NULL DEFAULT FALSE, -- the condition was met on the last evaluation
+    last_triggered_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Alerts evaluated against a refreshed catalogue
+CREATE INDEX IF NOT EXISTS idx_price_alerts_catalogue
+    ON price_alerts (app_id, currency) WHERE active;
+
+CREATE INDEX IF NOT EXISTS idx_price_alerts_user_id
+    ON price_alerts (user_id);
+
+-- Delivery log: one row per triggered alert with the result of its last attempt
+CREATE TABLE IF NOT EXISTS alert_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    alert_id INTEGER NOT NULL REFERENCES price_alerts(id) ON DELETE CASCADE,
+    event_id UUID UNIQUE NOT NULL,
+    price NUMERIC(15, 2) NOT NULL,
+    attempts INTEGER NOT NULL,
+    status_code INTEGER,
+    error VARCHAR,
+    success BOOLEAN NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_alert_deliveries_alert_id
+    ON alert_deliveries (alert_id, created_at);
@@ -0,0 +1,17 @@
+-- Catalogue purchases paid from the user balance
+
+CREATE TABLE IF NOT EXISTS orders (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    transaction_id INTEGER NOT NULL UNIQUE REFERENCES transactions(id), -- the debit that paid for the order
+    app_id INTEGER NOT NULL,
+    currency VARCHAR(3) NOT NULL,
+    market_hash_name VARCHAR NOT NULL,
+    tradable BOOLEAN NOT NULL,
+    price NUMERIC(15, 2) NOT NULL,     -- catalogue min price the order was charged
+    max_price NUMERIC(15, 2) NOT NULL, -- price guard supplied by the client
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_orders_user_id
+    ON orders (user_id);
@@ -0,0 +1,12 @@
+-- Transaction types, so the history tells credits from debits. amount stays positive, type gives the direction.
+
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS type VARCHAR(16);
+
+-- Every transaction before this migration was a debit
+UPDATE transactions SET type = 'purchase'
+WHERE type IS NULL AND id IN (SELECT transaction_id FROM orders);
+
+UPDATE transactions SET type = 'withdrawal'
+WHERE type IS NULL;
+
+ALTER TABLE transactions ALTER COLUMN type SET NOT NULL;
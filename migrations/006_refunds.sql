@@ -0,0 +1,8 @@
+-- Refunds are credit transactions referencing the debit they reverse
+
+ALTER TABLE transactions
+    ADD COLUMN IF NOT EXISTS reference_transaction_id INTEGER REFERENCES transactions(id);
+
+-- Sum of earlier refunds of a transaction
+CREATE INDEX IF NOT EXISTS idx_transactions_reference_transaction_id
+    ON transactions (reference_transaction_id) WHERE reference_transaction_id IS NOT NULL;
@@ -1,6 +1,8 @@
 package cache
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"sync"
 	"time"
@@ -11,6 +13,8 @@ import (
 type MemCache struct {
 	mem      map[string]cacheItem
 	mu       sync.RWMutex
+	loads    map[string]*loadCall
+	loadsMu  sync.Mutex
 	wg       sync.WaitGroup
 	syncOnce sync.Once
 	stopCh   chan struct{}
@@ -21,9 +25,20 @@ type cacheItem struct {
 	expiresAt time.Time
 }
 
+// LoadFunc produces a value for a missing cache key.
+type LoadFunc func(ctx context.Context) (any, error)
+
+// loadCall is an in-flight LoadFunc call shared by all callers waiting for the same key.
+type loadCall struct {
+	done    chan struct{}
+	payload any
+	err     error
+}
+
 func New(cacheCleanUpIntervalSeconds int) *MemCache {
 	m := &MemCache{
 		mem:    make(map[string]cacheItem),
+		loads:  make(map[string]*loadCall),
 		stopCh: make(chan struct{}),
 	}
 	m.wg.Add(1)
@@ -57,12 +72,86 @@ func (c *MemCache) Set(key string, payload any, ttl time.Duration) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	if c.mem == nil { // closed
+		return
+	}
+
 	c.mem[key] = cacheItem{
 		payload:   payload,
 		expiresAt: time.Now().Add(ttl),
 	}
 }
 
+// GetOrLoad returns the cached payload of key, or calls loader and caches its result for ttl.
+// Concurrent callers of a missing key share a single loader call. The loader is not cancelled when
+// callers give up, but every caller stops waiting as soon as its own context is done.
+// Loader errors are returned to all waiting callers and are not cached.
+func (c *MemCache) GetOrLoad(ctx context.Context, key string, ttl time.Duration, loader LoadFunc) (any, error) {
+	if payload, err := c.Get(key); err == nil {
+		return payload, nil
+	}
+
+	c.loadsMu.Lock()
+	if payload, err := c.Get(key); err == nil { // loaded while we were waiting for the lock
+		c.loadsMu.Unlock()
+		return payload, nil
+	}
+	call := c.startLoad(ctx, key, ttl, loader)
+	c.loadsMu.Unlock()
+
+	return call.wait(ctx)
+}
+
+// Reload calls loader and caches its result for ttl whether key is cached or not; until then Get keeps returning
+// the cached payload. A load of key already in flight, by GetOrLoad or Reload, is joined instead of starting
+// another one, so a key is never loaded twice at the same time.
+func (c *MemCache) Reload(ctx context.Context, key string, ttl time.Duration, loader LoadFunc) (any, error) {
+	c.loadsMu.Lock()
+	call := c.startLoad(ctx, key, ttl, loader)
+	c.loadsMu.Unlock()
+
+	return call.wait(ctx)
+}
+
+// startLoad returns the in-flight load of key or starts a new one. It must be called with c.loadsMu held.
+func (c *MemCache) startLoad(ctx context.Context, key string, ttl time.Duration, loader LoadFunc) *loadCall {
+	call, found := c.loads[key]
+	if !found {
+		call = &loadCall{done: make(chan struct{})}
+		c.loads[key] = call
+		go c.load(context.WithoutCancel(ctx), key, ttl, call, loader)
+	}
+	return call
+}
+
+func (call *loadCall) wait(ctx context.Context) (any, error) {
+	select {
+	case <-call.done:
+		return call.payload, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (c *MemCache) load(ctx context.Context, key string, ttl time.Duration, call *loadCall, loader LoadFunc) {
+	defer func() {
+		if r := recover(); r != nil {
+			call.payload, call.err = nil, fmt.Errorf("cache loader for %s panicked: %v", key, r)
+		}
+
+		c.loadsMu.Lock()
+		delete(c.loads, key)
+		c.loadsMu.Unlock()
+
+		close(call.done)
+	}()
+
+	call.payload, call.err = loader(ctx)
+	if call.err == nil {
+		c.Set(key, call.payload, ttl)
+	}
+}
+
 func (c *MemCache) Delete(keys ...string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
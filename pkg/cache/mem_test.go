@@ -1,9 +1,11 @@
 package cache
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -296,6 +298,180 @@ func TestMemCache_Close(t *testing.T) {
 	})
 }
 
+func TestMemCache_GetOrLoad(t *testing.T) {
+	cache := New(testCleanUpInterval)
+	defer cache.Close()
+
+	t.Run("cached value is returned without loading", func(t *testing.T) {
+		cache.Set("loaded", "cached", time.Second)
+
+		got, err := cache.GetOrLoad(context.Background(), "loaded", time.Second, func(ctx context.Context) (any, error) {
+			t.Error("loader should not be called for a cached key")
+			return nil, nil
+		})
+		if err != nil {
+			t.Fatalf("got unexpected error: %v", err)
+		}
+		if got != "cached" {
+			t.Errorf("got: %v, want: %v", got, "cached")
+		}
+	})
+
+	t.Run("concurrent misses share one load", func(t *testing.T) {
+		const goroutines = 50
+
+		var loads atomic.Int32
+		release := make(chan struct{})
+		loader := func(ctx context.Context) (any, error) {
+			loads.Add(1)
+			<-release
+			return "value", nil
+		}
+
+		var wg sync.WaitGroup
+		wg.Add(goroutines)
+		results := make(chan any, goroutines)
+		for range goroutines {
+			go func() {
+				defer wg.Done()
+				got, err := cache.GetOrLoad(context.Background(), "shared", time.Second, loader)
+				if err != nil {
+					t.Errorf("got unexpected error: %v", err)
+				}
+				results <- got
+			}()
+		}
+
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+		wg.Wait()
+		close(results)
+
+		if loads.Load() != 1 {
+			t.Errorf("loader should be called once, got %d", loads.Load())
+		}
+		for got := range results {
+			if got != "value" {
+				t.Errorf("got: %v, want: %v", got, "value")
+			}
+		}
+
+		if _, err := cache.Get("shared"); err != nil {
+			t.Errorf("loaded value should be cached: %v", err)
+		}
+	})
+
+	t.Run("errors are shared but not cached", func(t *testing.T) {
+		loadErr := errors.New("skinport is down")
+
+		_, err := cache.GetOrLoad(context.Background(), "failing", time.Second, func(ctx context.Context) (any, error) {
+			return nil, loadErr
+		})
+		if !errors.Is(err, loadErr) {
+			t.Errorf("got error = %v, want %v", err, loadErr)
+		}
+
+		got, err := cache.GetOrLoad(context.Background(), "failing", time.Second, func(ctx context.Context) (any, error) {
+			return "recovered", nil
+		})
+		if err != nil {
+			t.Fatalf("got unexpected error: %v", err)
+		}
+		if got != "recovered" {
+			t.Errorf("got: %v, want: %v", got, "recovered")
+		}
+	})
+
+	t.Run("waiting caller respects its context", func(t *testing.T) {
+		release := make(chan struct{})
+		defer close(release)
+
+		loaderCtxErr := make(chan error, 1)
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		_, err := cache.GetOrLoad(ctx, "slow", time.Second, func(ctx context.Context) (any, error) {
+			<-release
+			loaderCtxErr <- ctx.Err()
+			return "slow value", nil
+		})
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("got error = %v, want %v", err, context.DeadlineExceeded)
+		}
+
+		release <- struct{}{}
+		if err = <-loaderCtxErr; err != nil {
+			t.Errorf("loader context should outlive the caller, got %v", err)
+		}
+	})
+
+	t.Run("loader panic is returned as error", func(t *testing.T) {
+		_, err := cache.GetOrLoad(context.Background(), "panic", time.Second, func(ctx context.Context) (any, error) {
+			panic("boom")
+		})
+		if err == nil {
+			t.Error("expected error from panicking loader")
+		}
+	})
+}
+
+func TestMemCache_Reload(t *testing.T) {
+	cache := New(testCleanUpInterval)
+	defer cache.Close()
+
+	t.Run("cached key is reloaded", func(t *testing.T) {
+		cache.Set("reloaded", "old", time.Second)
+
+		got, err := cache.Reload(context.Background(), "reloaded", time.Second, func(ctx context.Context) (any, error) {
+			return "new", nil
+		})
+		if err != nil || got != "new" {
+			t.Fatalf("got %v, %v, want new", got, err)
+		}
+		if got, _ = cache.Get("reloaded"); got != "new" {
+			t.Errorf("got cached %v, want new", got)
+		}
+	})
+
+	t.Run("reload joins a load in flight", func(t *testing.T) {
+		var loads atomic.Int32
+		started, release := make(chan struct{}), make(chan struct{})
+		loader := func(ctx context.Context) (any, error) {
+			if loads.Add(1) == 1 {
+				close(started)
+			}
+			<-release
+			return "value", nil
+		}
+
+		missDone := make(chan error, 1)
+		go func() {
+			_, err := cache.GetOrLoad(context.Background(), "joined", time.Second, loader)
+			missDone <- err
+		}()
+		<-started
+
+		reloadDone := make(chan error, 1)
+		go func() {
+			_, err := cache.Reload(context.Background(), "joined", time.Second, loader)
+			reloadDone <- err
+		}()
+
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+
+		if err := <-missDone; err != nil {
+			t.Errorf("got unexpected error: %v", err)
+		}
+		if err := <-reloadDone; err != nil {
+			t.Errorf("got unexpected error: %v", err)
+		}
+		if loads.Load() != 1 {
+			t.Errorf("loader should be called once, got %d", loads.Load())
+		}
+	})
+}
+
 func BenchmarkMemCache_Set(b *testing.B) {
 	cache := New(testCleanUpInterval)
 	defer cache.Close()
@@ -0,0 +1,168 @@
+package circuitbreaker
+
+import (
+	errs "backend-test-golang/pkg/errors"
+	"log"
+	"sync"
+	"time"
+)
+
+type State int
+
+const (
+	// StateClosed lets every call through and counts consecutive failures.
+	StateClosed State = iota
+	// StateOpen rejects every call until the cool-down is over.
+	StateOpen
+	// StateHalfOpen lets a limited number of probe calls through to check whether the dependency is back.
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreaker stops calling a failing dependency for a while instead of waiting for its timeouts.
+// It opens after failureThreshold consecutive failures, stays open for coolDown and then lets
+// halfOpenRequests probe calls through. The breaker closes when all of them succeed and opens again
+// on any probe failure.
+//
+// Every call allowed by Allow must be reported with exactly one of Success, Failure or Release.
+type CircuitBreaker struct {
+	mu               sync.Mutex
+	name             string
+	failureThreshold int
+	coolDown         time.Duration
+	halfOpenRequests int
+
+	state     State
+	failures  int
+	openedAt  time.Time
+	probes    int // probe calls let through in the half-open state
+	successes int // successful probe calls
+}
+
+func New(name string, failureThreshold int, coolDown time.Duration, halfOpenRequests int) *CircuitBreaker {
+	return &CircuitBreaker{
+		name:             name,
+		failureThreshold: max(failureThreshold, 1),
+		coolDown:         coolDown,
+		halfOpenRequests: max(halfOpenRequests, 1),
+	}
+}
+
+// Allow returns ErrCircuitOpen when the call must not be made.
+func (cb *CircuitBreaker) Allow() error {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state == StateOpen {
+		if time.Since(cb.openedAt) < cb.coolDown {
+			return errs.NewCircuitOpenErr(cb.retryAfter())
+		}
+		cb.setState(StateHalfOpen)
+	}
+
+	if cb.state == StateHalfOpen {
+		if cb.probes >= cb.halfOpenRequests {
+			return errs.NewCircuitOpenErr(0)
+		}
+		cb.probes++
+	}
+
+	return nil
+}
+
+// Success reports that the dependency answered.
+func (cb *CircuitBreaker) Success() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state {
+	case StateClosed:
+		cb.failures = 0
+	case StateHalfOpen:
+		cb.successes++
+		if cb.successes >= cb.halfOpenRequests {
+			cb.setState(StateClosed)
+		}
+	}
+}
+
+// Failure reports that the dependency is unavailable.
+func (cb *CircuitBreaker) Failure() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state {
+	case StateClosed:
+		cb.failures++
+		if cb.failures >= cb.failureThreshold {
+			cb.setState(StateOpen)
+		}
+	case StateHalfOpen:
+		cb.setState(StateOpen)
+	}
+}
+
+// Release gives back an allowed call that was not made or whose outcome says nothing
+// about the dependency, e.g. it was cancelled by the caller.
+func (cb *CircuitBreaker) Release() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state == StateHalfOpen && cb.probes > 0 {
+		cb.probes--
+	}
+}
+
+// State returns the current state. An open breaker whose cool-down is over is reported as half-open.
+func (cb *CircuitBreaker) State() State {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state == StateOpen && time.Since(cb.openedAt) >= cb.coolDown {
+		return StateHalfOpen
+	}
+	return cb.state
+}
+
+// RetryAfter is how long the breaker stays open, zero when it is not open.
+func (cb *CircuitBreaker) RetryAfter() time.Duration {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state != StateOpen {
+		return 0
+	}
+	return cb.retryAfter()
+}
+
+func (cb *CircuitBreaker) retryAfter() time.Duration {
+	return max(cb.coolDown-time.Since(cb.openedAt), 0)
+}
+
+func (cb *CircuitBreaker) setState(state State) {
+	if cb.state == state {
+		return
+	}
+
+	log.Printf("[WARN] circuit breaker %s: %s -> %s\n", cb.name, cb.state, state)
+
+	cb.state = state
+	cb.failures = 0
+	cb.probes = 0
+	cb.successes = 0
+	if state == StateOpen {
+		cb.openedAt = time.Now()
+	}
+}
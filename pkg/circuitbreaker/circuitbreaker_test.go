@@ -0,0 +1,111 @@
+package circuitbreaker
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	errs "backend-test-golang/pkg/errors"
+)
+
+func isOpenErr(err error) bool {
+	var circuitOpen *errs.ErrCircuitOpen
+	return errors.As(err, &circuitOpen)
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	t.Run("opens after consecutive failures", func(t *testing.T) {
+		cb := New("test", 3, time.Minute, 1)
+
+		for i := 0; i < 2; i++ {
+			if err := cb.Allow(); err != nil {
+				t.Fatalf("got unexpected error: %v", err)
+			}
+			cb.Failure()
+		}
+		if cb.State() != StateClosed {
+			t.Fatalf("got state %s, want closed", cb.State())
+		}
+
+		_ = cb.Allow()
+		cb.Failure()
+
+		if cb.State() != StateOpen {
+			t.Fatalf("got state %s, want open", cb.State())
+		}
+		if err := cb.Allow(); !isOpenErr(err) {
+			t.Errorf("got error %v, want circuit open error", err)
+		}
+		if retryAfter := cb.RetryAfter(); retryAfter <= 0 || retryAfter > time.Minute {
+			t.Errorf("got retry after %v, want up to a minute", retryAfter)
+		}
+	})
+
+	t.Run("success resets the failure count", func(t *testing.T) {
+		cb := New("test", 2, time.Minute, 1)
+
+		cb.Failure()
+		cb.Success()
+		cb.Failure()
+
+		if cb.State() != StateClosed {
+			t.Errorf("got state %s, want closed", cb.State())
+		}
+	})
+
+	t.Run("half-open closes after successful probes", func(t *testing.T) {
+		cb := New("test", 1, 10*time.Millisecond, 2)
+		cb.Failure()
+		time.Sleep(20 * time.Millisecond)
+
+		if cb.State() != StateHalfOpen {
+			t.Fatalf("got state %s, want half-open", cb.State())
+		}
+
+		for i := 0; i < 2; i++ {
+			if err := cb.Allow(); err != nil {
+				t.Fatalf("probe %d: got unexpected error: %v", i, err)
+			}
+		}
+		if err := cb.Allow(); !isOpenErr(err) {
+			t.Errorf("got error %v, want only 2 probes", err)
+		}
+
+		cb.Success()
+		if cb.State() != StateHalfOpen {
+			t.Fatalf("got state %s, want half-open until every probe succeeds", cb.State())
+		}
+		cb.Success()
+		if cb.State() != StateClosed {
+			t.Errorf("got state %s, want closed", cb.State())
+		}
+	})
+
+	t.Run("half-open failure opens again", func(t *testing.T) {
+		cb := New("test", 1, 10*time.Millisecond, 1)
+		cb.Failure()
+		time.Sleep(20 * time.Millisecond)
+
+		if err := cb.Allow(); err != nil {
+			t.Fatalf("got unexpected error: %v", err)
+		}
+		cb.Failure()
+
+		if cb.State() != StateOpen {
+			t.Errorf("got state %s, want open", cb.State())
+		}
+	})
+
+	t.Run("released probe can be retried", func(t *testing.T) {
+		cb := New("test", 1, 10*time.Millisecond, 1)
+		cb.Failure()
+		time.Sleep(20 * time.Millisecond)
+
+		_ = cb.Allow()
+		cb.Release()
+
+		if err := cb.Allow(); err != nil {
+			t.Errorf("got error %v, want the released probe slot back", err)
+		}
+	})
+}
@@ -7,11 +7,23 @@ import (
 )
 
 var (
-	ErrNotFound            = errors.New("not found")
-	ErrInvalidCacheEntry   = errors.New("invalid cache entry")
-	ErrValidationFailed    = errors.New("validation failed")
-	ErrUserNotFound        = errors.New("user not found")
-	ErrInsufficientBalance = errors.New("insufficient balance")
+	ErrNotFound             = errors.New("not found")
+	ErrInvalidCacheEntry    = errors.New("invalid cache entry")
+	ErrValidationFailed     = errors.New("validation failed")
+	ErrUserNotFound         = errors.New("user not found")
+	ErrInsufficientBalance  = errors.New("insufficient balance")
+	ErrBalanceLimitExceeded = errors.New("balance limit exceeded")
+	ErrItemNotFound         = errors.New("item not found")
+	ErrChangesExpired       = errors.New("catalogue changes are no longer available")
+	ErrAlertNotFound        = errors.New("alert not found")
+	ErrAlertLimitReached    = errors.New("alert limit reached")
+	ErrItemUnavailable      = errors.New("item is not available")
+	ErrPriceAboveLimit      = errors.New("price is above the max price")
+	ErrPriceNotCurrent      = errors.New("current price is not known")
+	ErrTransactionNotFound  = errors.New("transaction not found")
+	ErrNotRefundable        = errors.New("transaction can not be refunded")
+	ErrRefundExceedsAmount  = errors.New("refund exceeds the refundable amount")
+	ErrIdempotencyKeyUsed   = errors.New("idempotency key is already used")
 )
 
 type ErrRateLimitExceed struct {
@@ -29,3 +41,15 @@ func (e *ErrRateLimitExceed) Error() string {
 func (e *ErrRateLimitExceed) ErrMsgWithRetry() string {
 	return fmt.Sprintf("rate limit exceed: please retry after %v", e.RetryAfter)
 }
+
+type ErrCircuitOpen struct {
+	RetryAfter time.Duration
+}
+
+func NewCircuitOpenErr(retryAfter time.Duration) *ErrCircuitOpen {
+	return &ErrCircuitOpen{RetryAfter: retryAfter}
+}
+
+func (e *ErrCircuitOpen) Error() string {
+	return "circuit breaker is open"
+}
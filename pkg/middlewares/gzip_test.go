@@ -0,0 +1,125 @@
+package middlewares
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestGzipEncode_Flush(t *testing.T) {
+	release := make(chan struct{})
+	handler := GzipEncode(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rc := http.NewResponseController(w)
+		if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+			t.Errorf("failed to clear write deadline through the gzip writer: %v", err)
+		}
+
+		_, _ = io.WriteString(w, "data: first\n\n")
+		if err := rc.Flush(); err != nil {
+			t.Errorf("failed to flush: %v", err)
+		}
+
+		<-release
+		_, _ = io.WriteString(w, "data: second\n\n")
+	}))
+
+	server := httptest.NewUnstartedServer(handler)
+	server.Config.WriteTimeout = time.Second
+	server.Start()
+	defer server.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.Header.Get("Content-Encoding") != "gzip" {
+		t.Fatalf("got Content-Encoding %q, want gzip", resp.Header.Get("Content-Encoding"))
+	}
+
+	gz, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		t.Fatalf("failed to read gzip stream: %v", err)
+	}
+	body := bufio.NewReader(gz)
+
+	// The first event must arrive while the handler is still running.
+	line, err := body.ReadString('\n')
+	if err != nil || line != "data: first\n" {
+		t.Fatalf("got %q, %v before the handler finished, want the flushed event", line, err)
+	}
+
+	// The stream outlives the server write timeout.
+	time.Sleep(1200 * time.Millisecond)
+	close(release)
+
+	rest, err := io.ReadAll(body)
+	if err != nil || string(rest) != "\ndata: second\n\n" {
+		t.Errorf("got %q, %v, want the rest of the stream", rest, err)
+	}
+}
+
+func TestGzipEncode(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       string
+		status       int
+		body         string
+		wantEncoding string
+	}{
+		{name: "ok", method: http.MethodGet, status: http.StatusOK, body: `{"success":true}`, wantEncoding: "gzip"},
+		{name: "not modified", method: http.MethodGet, status: http.StatusNotModified},
+		{name: "no content", method: http.MethodDelete, status: http.StatusNoContent},
+		{name: "head", method: http.MethodHead, status: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := GzipEncode(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				if tt.body != "" {
+					_, _ = io.WriteString(w, tt.body)
+				}
+			}))
+
+			req := httptest.NewRequest(tt.method, "/api/v1/items", nil)
+			req.Header.Set("Accept-Encoding", "gzip")
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("got status %d, want %d", rec.Code, tt.status)
+			}
+			if got := rec.Header().Get("Content-Encoding"); got != tt.wantEncoding {
+				t.Errorf("got Content-Encoding %q, want %q", got, tt.wantEncoding)
+			}
+			if got := rec.Header().Get("Vary"); got != "Accept-Encoding" {
+				t.Errorf("got Vary %q, want Accept-Encoding", got)
+			}
+
+			if tt.wantEncoding == "" {
+				if rec.Body.Len() != 0 {
+					t.Errorf("got body %q, want none", rec.Body.Bytes())
+				}
+				return
+			}
+
+			gz, err := gzip.NewReader(rec.Body)
+			if err != nil {
+				t.Fatalf("failed to read gzip stream: %v", err)
+			}
+			if body, err := io.ReadAll(gz); err != nil || string(body) != tt.body {
+				t.Errorf("got body %q, %v, want %q", body, err, tt.body)
+			}
+		})
+	}
+}
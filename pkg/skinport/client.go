@@ -1,35 +1,96 @@
 package skinport
 
 import (
+	"backend-test-golang/pkg/circuitbreaker"
 	errs "backend-test-golang/pkg/errors"
 	"backend-test-golang/pkg/ratelimiter"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"github.com/andybalholm/brotli"
 	"io"
 	"log"
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 	"time"
 )
 
 const (
-	defaultAppID    = 730
-	defaultCurrency = "EUR"
+	DefaultAppID    = 730
+	DefaultCurrency = "EUR"
 )
 
+// supportedAppIDs are the games Skinport has a market for: CS2, Dota 2, Rust and TF2.
+var supportedAppIDs = map[int]struct{}{
+	730:    {},
+	570:    {},
+	252490: {},
+	440:    {},
+}
+
+// supportedCurrencies are the currencies Skinport API can price items in.
+var supportedCurrencies = map[string]struct{}{
+	"AUD": {}, "BRL": {}, "CAD": {}, "CHF": {}, "CNY": {}, "CZK": {}, "DKK": {}, "EUR": {},
+	"GBP": {}, "HRK": {}, "NOK": {}, "PLN": {}, "RUB": {}, "SEK": {}, "TRY": {}, "USD": {},
+}
+
+func ValidateAppID(appID int) error {
+	if _, ok := supportedAppIDs[appID]; !ok {
+		return fmt.Errorf("unsupported app_id %d", appID)
+	}
+	return nil
+}
+
+func ValidateCurrency(currency string) error {
+	if _, ok := supportedCurrencies[currency]; !ok {
+		return fmt.Errorf("unsupported currency %q", currency)
+	}
+	return nil
+}
+
 type Client struct {
 	clientID    string
 	secretKey   string
 	baseURL     string
 	client      *http.Client
 	rateLimiter *ratelimiter.RateLimiter
+	retryPolicy RetryPolicy
+	breaker     *circuitbreaker.CircuitBreaker
+	maxBodySize int64
+}
+
+// Option customizes a Client created by NewClient.
+type Option func(*Client)
+
+// WithRetryPolicy sets how transient failures are retried. By default, requests are not retried.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *Client) {
+		if policy.MaxAttempts < 1 {
+			policy.MaxAttempts = 1
+		}
+		c.retryPolicy = policy
+	}
+}
+
+// WithCircuitBreaker replaces the default breaker (5 failures, 30 seconds cool-down, 1 probe).
+func WithCircuitBreaker(breaker *circuitbreaker.CircuitBreaker) Option {
+	return func(c *Client) {
+		c.breaker = breaker
+	}
+}
+
+// WithMaxResponseSize limits the decompressed size of a response body, DefaultMaxResponseSize by default.
+func WithMaxResponseSize(size int64) Option {
+	return func(c *Client) {
+		if size > 0 {
+			c.maxBodySize = size
+		}
+	}
 }
 
-func NewClient(clientID, secretKey, baseURL string) (*Client, error) {
+func NewClient(clientID, secretKey, baseURL string, opts ...Option) (*Client, error) {
 	u, err := url.ParseRequestURI(baseURL)
 	if err != nil {
 		return nil, fmt.Errorf("could not parse skinport base url: %w", err)
@@ -43,13 +104,22 @@ func NewClient(clientID, secretKey, baseURL string) (*Client, error) {
 		return nil, errors.New("missing skinport url host")
 	}
 
-	return &Client{
+	c := &Client{
 		clientID:    clientID,
 		secretKey:   secretKey,
 		baseURL:     baseURL,
 		client:      &http.Client{Timeout: 10 * time.Second},
 		rateLimiter: ratelimiter.New(8, 5*time.Minute), // skinport API's rate limits
-	}, nil
+		retryPolicy: NoRetry,
+		breaker:     circuitbreaker.New("skinport", 5, 30*time.Second, 1),
+		maxBodySize: DefaultMaxResponseSize,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c, nil
 }
 
 type Item struct {
@@ -68,59 +138,264 @@ type Item struct {
 	Tradable       bool     `json:"tradable"`
 }
 
-func (c *Client) GetItems(ctx context.Context, tradable bool) ([]Item, error) {
-	if !c.rateLimiter.Allow() {
-		return nil, errs.NewRateLimitExceedErr(c.rateLimiter.RetryAfter())
+// GetItems returns the whole catalogue of tradable or non-tradable items.
+// Use StreamItems to process a large catalogue without holding it in memory.
+func (c *Client) GetItems(ctx context.Context, appID int, currency string, tradable bool) ([]Item, error) {
+	var items []Item
+	err := c.StreamItems(ctx, appID, currency, tradable, func(item Item) error {
+		items = append(items, item)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	u, _ := url.Parse(c.baseURL)
-	u = u.JoinPath("/items")
+	return items, nil
+}
 
-	q := u.Query()
-	q.Set("currency", defaultCurrency)
-	q.Set("app_id", fmt.Sprintf("%d", defaultAppID))
+// StreamItems calls fn for every item of the catalogue as it is decoded from the response.
+// When a request is retried after a failure in the middle of the body, fn may get the same item again.
+func (c *Client) StreamItems(ctx context.Context, appID int, currency string, tradable bool, fn func(Item) error) error {
+	if err := validateParams(appID, currency); err != nil {
+		return err
+	}
+
+	q := url.Values{}
+	q.Set("currency", currency)
+	q.Set("app_id", strconv.Itoa(appID))
 	q.Set("tradable", fmt.Sprintf("%t", tradable))
-	u.RawQuery = q.Encode()
 
+	return c.get(ctx, "/items", q, func(r io.Reader) error {
+		return DecodeItems(r, func(item Item) error {
+			item.Tradable = tradable
+			return fn(item)
+		})
+	})
+}
+
+type SalesHistory struct {
+	MarketHashName string     `json:"market_hash_name"`
+	Version        string     `json:"version"`
+	Currency       string     `json:"currency"`
+	ItemPage       string     `json:"item_page"`
+	MarketPage     string     `json:"market_page"`
+	Last24Hours    SalesStats `json:"last_24_hours"`
+	Last7Days      SalesStats `json:"last_7_days"`
+	Last30Days     SalesStats `json:"last_30_days"`
+	Last90Days     SalesStats `json:"last_90_days"`
+}
+
+type SalesStats struct {
+	Min    *float64 `json:"min"`
+	Max    *float64 `json:"max"`
+	Avg    *float64 `json:"avg"`
+	Median *float64 `json:"median"`
+	Volume int64    `json:"volume"`
+}
+
+// GetSalesHistory returns aggregated sales of the last 24 hours, 7, 30 and 90 days.
+// Without market hash names Skinport returns the history of every item of the app.
+func (c *Client) GetSalesHistory(ctx context.Context, appID int, currency string, marketHashNames ...string) ([]SalesHistory, error) {
+	if err := validateParams(appID, currency); err != nil {
+		return nil, err
+	}
+
+	q := url.Values{}
+	q.Set("currency", currency)
+	q.Set("app_id", strconv.Itoa(appID))
+	if len(marketHashNames) > 0 {
+		q.Set("market_hash_name", strings.Join(marketHashNames, ","))
+	}
+
+	var history []SalesHistory
+	if err := c.get(ctx, "/sales/history", q, decodeJSON(&history)); err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+type OutOfStockItem struct {
+	MarketHashName string   `json:"market_hash_name"`
+	Version        string   `json:"version"`
+	Currency       string   `json:"currency"`
+	SuggestedPrice *float64 `json:"suggested_price"`
+	AvgSalePrice   *float64 `json:"avg_sale_price"`
+	SalesLast90d   int64    `json:"sales_last_90d"`
+}
+
+// GetOutOfStock returns items that have been sold recently but currently have no listings.
+func (c *Client) GetOutOfStock(ctx context.Context, appID int, currency string) ([]OutOfStockItem, error) {
+	if err := validateParams(appID, currency); err != nil {
+		return nil, err
+	}
+
+	q := url.Values{}
+	q.Set("currency", currency)
+	q.Set("app_id", strconv.Itoa(appID))
+
+	var items []OutOfStockItem
+	if err := c.get(ctx, "/sales/out-of-stock", q, decodeJSON(&items)); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func validateParams(appID int, currency string) error {
+	if err := ValidateAppID(appID); err != nil {
+		return err
+	}
+
+	return ValidateCurrency(currency)
+}
+
+// get calls a Skinport endpoint and passes its decompressed JSON body to decode.
+// Transient failures are retried according to the retry policy. All endpoints share one rate limiter
+// and every attempt spends a token of it. While the circuit breaker is open no request is made.
+func (c *Client) get(ctx context.Context, path string, query url.Values, decode func(io.Reader) error) error {
+	u, _ := url.Parse(c.baseURL)
+	u = u.JoinPath(path)
+	u.RawQuery = query.Encode()
+
+	for attempt := 1; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if err := c.breaker.Allow(); err != nil {
+			return err
+		}
+
+		if !c.rateLimiter.Allow() {
+			c.breaker.Release()
+			return errs.NewRateLimitExceedErr(c.rateLimiter.RetryAfter())
+		}
+
+		err := c.do(ctx, u.String(), decode)
+		c.report(ctx, err)
+		if err == nil || attempt >= c.retryPolicy.MaxAttempts || ctx.Err() != nil || !c.retryPolicy.retryable(err) {
+			return err
+		}
+
+		wait := c.retryPolicy.backoff(attempt)
+
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) && statusErr.RetryAfter > wait {
+			wait = statusErr.RetryAfter
+		}
+
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			return err
+		}
+
+		log.Printf("[WARN] skinport %s: attempt %d/%d failed, retrying in %v: %v\n", path, attempt, c.retryPolicy.MaxAttempts, wait, err)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+	}
+}
+
+// do makes a single request to Skinport.
+func (c *Client) do(ctx context.Context, u string, decode func(io.Reader) error) error {
 	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	if c.clientID != "" && c.secretKey != "" {
 		req.SetBasicAuth(c.clientID, c.secretKey)
 	}
 
-	req.Header.Set("Accept-Encoding", "br")
+	req.Header.Set("Accept-Encoding", acceptEncoding)
 
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusTooManyRequests {
-		return nil, c.tooManyRequestErr(resp.Header.Get("Retry-After"))
+		return c.tooManyRequestErr(resp.Header.Get("Retry-After"))
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("skinport API returned status %d: %s", resp.StatusCode, string(body))
+		statusErr := &StatusError{StatusCode: resp.StatusCode, Body: readErrorBody(resp)}
+		if resp.StatusCode == http.StatusServiceUnavailable {
+			statusErr.RetryAfter, _ = parseRetryAfter(resp.Header.Get("Retry-After"))
+		}
+		return statusErr
 	}
 
-	var items []Item
-	if err = json.NewDecoder(io.NopCloser(brotli.NewReader(resp.Body))).Decode(&items); err != nil {
-		return nil, err
+	body, err := decodeBody(resp, c.maxBodySize)
+	if err != nil {
+		return err
 	}
+	defer body.Close()
 
-	for i := range items {
-		items[i].Tradable = tradable
+	if err = decode(body); err != nil {
+		return fmt.Errorf("could not decode skinport response (Content-Encoding %q): %w", resp.Header.Get("Content-Encoding"), err)
 	}
 
-	return items, nil
+	return nil
+}
+
+func decodeJSON(out any) func(io.Reader) error {
+	return func(r io.Reader) error {
+		return json.NewDecoder(r).Decode(out)
+	}
+}
+
+// readErrorBody returns the beginning of an error response body, decoded if possible.
+func readErrorBody(resp *http.Response) string {
+	body, err := decodeBody(resp, maxErrorBodySize)
+	if err != nil {
+		return ""
+	}
+	defer body.Close()
+
+	b, _ := io.ReadAll(body)
+	return string(b)
+}
+
+// report tells the circuit breaker whether Skinport answered. Client errors and rate limiting
+// mean Skinport is up; calls cancelled by the caller say nothing about it.
+func (c *Client) report(ctx context.Context, err error) {
+	var statusErr *StatusError
+	switch {
+	case err == nil:
+		c.breaker.Success()
+	case ctx.Err() != nil:
+		c.breaker.Release()
+	case errors.As(err, &statusErr):
+		if statusErr.StatusCode >= http.StatusInternalServerError {
+			c.breaker.Failure()
+		} else {
+			c.breaker.Success()
+		}
+	case unavailable(err):
+		c.breaker.Failure()
+	default:
+		c.breaker.Success()
+	}
+}
+
+// CircuitState is the state of the circuit breaker around Skinport calls.
+func (c *Client) CircuitState() circuitbreaker.State {
+	return c.breaker.State()
+}
+
+// RemainingRequests is how many Skinport calls can be made right now without hitting the rate limit.
+func (c *Client) RemainingRequests() int {
+	return c.rateLimiter.Remaining()
 }
 
 func (c *Client) tooManyRequestErr(retryAfter string) error {
@@ -0,0 +1,262 @@
+package skinport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"backend-test-golang/pkg/circuitbreaker"
+	errs "backend-test-golang/pkg/errors"
+
+	"github.com/andybalholm/brotli"
+)
+
+var testRetryPolicy = RetryPolicy{
+	MaxAttempts:       3,
+	BaseBackoff:       time.Millisecond,
+	MaxBackoff:        5 * time.Millisecond,
+	RetryableStatuses: []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout},
+}
+
+// newTestServer answers with the given statuses in turn and with a brotli encoded single item list after them.
+func newTestServer(t *testing.T, header http.Header, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		call := int(calls.Add(1))
+		if call <= len(statuses) {
+			for key, values := range header {
+				w.Header()[key] = values
+			}
+			w.WriteHeader(statuses[call-1])
+			return
+		}
+
+		w.Header().Set("Content-Encoding", "br")
+		bw := brotli.NewWriter(w)
+		_ = json.NewEncoder(bw).Encode([]Item{{MarketHashName: "AK-47 | Redline (Field-Tested)", Currency: "EUR"}})
+		_ = bw.Close()
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &calls
+}
+
+func newTestClient(t *testing.T, baseURL string, policy RetryPolicy) *Client {
+	t.Helper()
+
+	c, err := NewClient("", "", baseURL, WithRetryPolicy(policy))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	return c
+}
+
+func TestClient_Retry(t *testing.T) {
+	t.Run("retries server errors and succeeds", func(t *testing.T) {
+		server, calls := newTestServer(t, nil, http.StatusBadGateway, http.StatusInternalServerError)
+		c := newTestClient(t, server.URL, testRetryPolicy)
+
+		items, err := c.GetItems(context.Background(), DefaultAppID, DefaultCurrency, true)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(items) != 1 || !items[0].Tradable {
+			t.Errorf("got items %+v, want one tradable item", items)
+		}
+		if got := calls.Load(); got != 3 {
+			t.Errorf("got %d calls, want 3", got)
+		}
+	})
+
+	t.Run("every attempt spends a rate limit token", func(t *testing.T) {
+		server, _ := newTestServer(t, nil, http.StatusServiceUnavailable)
+		c := newTestClient(t, server.URL, testRetryPolicy)
+		before := c.RemainingRequests()
+
+		if _, err := c.GetItems(context.Background(), DefaultAppID, DefaultCurrency, true); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := before - c.RemainingRequests(); got != 2 {
+			t.Errorf("got %d tokens spent, want 2", got)
+		}
+	})
+
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		server, calls := newTestServer(t, nil, http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway)
+		c := newTestClient(t, server.URL, testRetryPolicy)
+
+		_, err := c.GetItems(context.Background(), DefaultAppID, DefaultCurrency, true)
+
+		var statusErr *StatusError
+		if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusBadGateway {
+			t.Fatalf("got error %v, want status error 502", err)
+		}
+		if got := calls.Load(); got != 3 {
+			t.Errorf("got %d calls, want 3", got)
+		}
+	})
+
+	t.Run("does not retry client errors", func(t *testing.T) {
+		server, calls := newTestServer(t, nil, http.StatusBadRequest)
+		c := newTestClient(t, server.URL, testRetryPolicy)
+
+		if _, err := c.GetItems(context.Background(), DefaultAppID, DefaultCurrency, true); err == nil {
+			t.Fatal("expected an error")
+		}
+		if got := calls.Load(); got != 1 {
+			t.Errorf("got %d calls, want 1", got)
+		}
+	})
+
+	t.Run("does not retry rate limit responses", func(t *testing.T) {
+		server, calls := newTestServer(t, http.Header{"Retry-After": {"60"}}, http.StatusTooManyRequests)
+		c := newTestClient(t, server.URL, testRetryPolicy)
+
+		_, err := c.GetItems(context.Background(), DefaultAppID, DefaultCurrency, true)
+		var rateLimitErr *errs.ErrRateLimitExceed
+		if !errors.As(err, &rateLimitErr) {
+			t.Fatalf("got error %v, want rate limit error", err)
+		}
+		if got := calls.Load(); got != 1 {
+			t.Errorf("got %d calls, want 1", got)
+		}
+	})
+
+	t.Run("honours retry after of 503", func(t *testing.T) {
+		server, calls := newTestServer(t, http.Header{"Retry-After": {"1"}}, http.StatusServiceUnavailable)
+		c := newTestClient(t, server.URL, testRetryPolicy)
+
+		start := time.Now()
+		if _, err := c.GetItems(context.Background(), DefaultAppID, DefaultCurrency, true); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if elapsed := time.Since(start); elapsed < time.Second {
+			t.Errorf("retried after %v, want at least 1s", elapsed)
+		}
+		if got := calls.Load(); got != 2 {
+			t.Errorf("got %d calls, want 2", got)
+		}
+	})
+
+	t.Run("does not wait past the context deadline", func(t *testing.T) {
+		server, calls := newTestServer(t, http.Header{"Retry-After": {"60"}}, http.StatusServiceUnavailable)
+		c := newTestClient(t, server.URL, testRetryPolicy)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		start := time.Now()
+		if _, err := c.GetItems(ctx, DefaultAppID, DefaultCurrency, true); err == nil {
+			t.Fatal("expected an error")
+		}
+		if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+			t.Errorf("returned after %v, want immediately", elapsed)
+		}
+		if got := calls.Load(); got != 1 {
+			t.Errorf("got %d calls, want 1", got)
+		}
+	})
+
+	t.Run("stops when the context is cancelled", func(t *testing.T) {
+		server, calls := newTestServer(t, nil, http.StatusBadGateway, http.StatusBadGateway)
+		policy := testRetryPolicy
+		policy.BaseBackoff, policy.MaxBackoff = time.Minute, time.Minute
+		c := newTestClient(t, server.URL, policy)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(50*time.Millisecond, cancel)
+
+		if _, err := c.GetItems(ctx, DefaultAppID, DefaultCurrency, true); err == nil {
+			t.Fatal("expected an error")
+		}
+		if got := calls.Load(); got != 1 {
+			t.Errorf("got %d calls, want 1", got)
+		}
+	})
+}
+
+func TestClient_CircuitBreaker(t *testing.T) {
+	t.Run("open breaker fails fast", func(t *testing.T) {
+		server, calls := newTestServer(t, nil, http.StatusBadGateway, http.StatusBadGateway)
+		c, err := NewClient("", "", server.URL, WithCircuitBreaker(circuitbreaker.New("test", 2, time.Minute, 1)))
+		if err != nil {
+			t.Fatalf("failed to create client: %v", err)
+		}
+
+		for i := 0; i < 2; i++ {
+			if _, err = c.GetItems(context.Background(), DefaultAppID, DefaultCurrency, true); err == nil {
+				t.Fatal("expected an error")
+			}
+		}
+		if c.CircuitState() != circuitbreaker.StateOpen {
+			t.Fatalf("got state %s, want open", c.CircuitState())
+		}
+
+		remaining := c.RemainingRequests()
+		_, err = c.GetItems(context.Background(), DefaultAppID, DefaultCurrency, true)
+
+		var circuitOpen *errs.ErrCircuitOpen
+		if !errors.As(err, &circuitOpen) {
+			t.Fatalf("got error %v, want circuit open error", err)
+		}
+		if got := calls.Load(); got != 2 {
+			t.Errorf("got %d calls, want 2", got)
+		}
+		if c.RemainingRequests() != remaining {
+			t.Error("rejected calls must not spend the rate limit")
+		}
+	})
+
+	t.Run("client errors do not open the breaker", func(t *testing.T) {
+		server, _ := newTestServer(t, nil, http.StatusBadRequest, http.StatusNotFound)
+		c, err := NewClient("", "", server.URL, WithCircuitBreaker(circuitbreaker.New("test", 2, time.Minute, 1)))
+		if err != nil {
+			t.Fatalf("failed to create client: %v", err)
+		}
+
+		for i := 0; i < 2; i++ {
+			_, _ = c.GetItems(context.Background(), DefaultAppID, DefaultCurrency, true)
+		}
+		if c.CircuitState() != circuitbreaker.StateClosed {
+			t.Errorf("got state %s, want closed", c.CircuitState())
+		}
+	})
+
+	t.Run("half-open probe closes the breaker", func(t *testing.T) {
+		server, _ := newTestServer(t, nil, http.StatusInternalServerError)
+		c, err := NewClient("", "", server.URL, WithCircuitBreaker(circuitbreaker.New("test", 1, 10*time.Millisecond, 1)))
+		if err != nil {
+			t.Fatalf("failed to create client: %v", err)
+		}
+
+		_, _ = c.GetItems(context.Background(), DefaultAppID, DefaultCurrency, true)
+		time.Sleep(20 * time.Millisecond)
+
+		if _, err = c.GetItems(context.Background(), DefaultAppID, DefaultCurrency, true); err != nil {
+			t.Fatalf("got unexpected error: %v", err)
+		}
+		if c.CircuitState() != circuitbreaker.StateClosed {
+			t.Errorf("got state %s, want closed", c.CircuitState())
+		}
+	})
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{BaseBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Jitter: 0.2}
+
+	for retry, want := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 3: 400 * time.Millisecond, 5: time.Second, 40: time.Second} {
+		for range 20 {
+			got := policy.backoff(retry)
+			if got < want*8/10 || got > want*12/10 {
+				t.Errorf("retry %d: got backoff %v, want %v +/- 20%%", retry, got, want)
+			}
+		}
+	}
+}
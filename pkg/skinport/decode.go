@@ -0,0 +1,142 @@
+package skinport
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+// acceptEncoding lists the encodings decodeBody understands, best compression first.
+const acceptEncoding = "br, gzip, zstd"
+
+// DefaultMaxResponseSize is the decompressed size limit of a Skinport response body.
+const DefaultMaxResponseSize = 128 << 20
+
+// maxErrorBodySize is how much of an error response body is kept in StatusError.
+const maxErrorBodySize = 1 << 10
+
+var (
+	ErrResponseTooLarge    = errors.New("skinport response is too large")
+	ErrUnsupportedEncoding = errors.New("unsupported skinport response encoding")
+)
+
+// decodeBody returns the response body decoded according to its Content-Encoding.
+// Reading more than limit decompressed bytes fails with ErrResponseTooLarge.
+func decodeBody(resp *http.Response, limit int64) (io.ReadCloser, error) {
+	var (
+		r       io.Reader = resp.Body
+		closers []io.Closer
+	)
+
+	// Encodings are listed in the order they were applied, so they are undone from the last one.
+	codings := strings.Split(resp.Header.Get("Content-Encoding"), ",")
+	for i := len(codings) - 1; i >= 0; i-- {
+		coding := strings.ToLower(strings.TrimSpace(codings[i]))
+
+		switch coding {
+		case "", "identity":
+		case "br":
+			r = brotli.NewReader(r)
+		case "gzip", "x-gzip":
+			gr, err := gzip.NewReader(r)
+			if err != nil {
+				closeAll(closers)
+				return nil, fmt.Errorf("could not read gzip response: %w", err)
+			}
+			closers = append(closers, gr)
+			r = gr
+		case "deflate":
+			dr, err := newDeflateReader(r)
+			if err != nil {
+				closeAll(closers)
+				return nil, fmt.Errorf("could not read deflate response: %w", err)
+			}
+			closers = append(closers, dr)
+			r = dr
+		case "zstd":
+			zr, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxMemory(uint64(limit)))
+			if err != nil {
+				closeAll(closers)
+				return nil, fmt.Errorf("could not read zstd response: %w", err)
+			}
+			closer := zr.IOReadCloser()
+			closers = append(closers, closer)
+			r = closer
+		default:
+			closeAll(closers)
+			return nil, fmt.Errorf("%w: %q", ErrUnsupportedEncoding, coding)
+		}
+	}
+
+	return &limitedBody{r: r, remaining: limit, closers: closers}, nil
+}
+
+// newDeflateReader reads "deflate" bodies, which per RFC 9110 are zlib streams,
+// but some servers send raw deflate data without the zlib header.
+func newDeflateReader(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+
+	header, err := br.Peek(2)
+	if err != nil {
+		return nil, err
+	}
+
+	if header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(br)
+	}
+
+	return flate.NewReader(br), nil
+}
+
+// limitedBody fails instead of truncating the body when it is larger than the limit,
+// so that a decompression bomb is not decoded as valid but cut JSON.
+type limitedBody struct {
+	r         io.Reader
+	remaining int64
+	closers   []io.Closer
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining <= 0 {
+		// The limit is reached, the body fits only if nothing is left to read.
+		var probe [1]byte
+		if n, _ := io.ReadFull(b.r, probe[:]); n > 0 {
+			return 0, ErrResponseTooLarge
+		}
+		return 0, io.EOF
+	}
+
+	if int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+
+	n, err := b.r.Read(p)
+	b.remaining -= int64(n)
+
+	// zstd refuses frames whose window or declared size does not fit the limit before decoding them.
+	if errors.Is(err, zstd.ErrWindowSizeExceeded) || errors.Is(err, zstd.ErrDecoderSizeExceeded) {
+		err = ErrResponseTooLarge
+	}
+
+	return n, err
+}
+
+func (b *limitedBody) Close() error {
+	closeAll(b.closers)
+	return nil
+}
+
+func closeAll(closers []io.Closer) {
+	for i := len(closers) - 1; i >= 0; i-- {
+		_ = closers[i].Close()
+	}
+}
@@ -0,0 +1,168 @@
+package skinport
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/zstd"
+)
+
+func encode(t *testing.T, encoding string, data []byte) []byte {
+	t.Helper()
+
+	var (
+		buf bytes.Buffer
+		w   io.WriteCloser
+		err error
+	)
+	switch encoding {
+	case "", "identity":
+		return data
+	case "br":
+		w = brotli.NewWriter(&buf)
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "deflate":
+		w = zlib.NewWriter(&buf)
+	case "raw-deflate":
+		w, err = flate.NewWriter(&buf, flate.DefaultCompression)
+	case "zstd":
+		w, err = zstd.NewWriter(&buf)
+	default:
+		t.Fatalf("unknown encoding %q", encoding)
+	}
+	if err != nil {
+		t.Fatalf("failed to create %s writer: %v", encoding, err)
+	}
+
+	if _, err = w.Write(data); err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+	if err = w.Close(); err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// newEncodingServer answers every request with body sent as is and the given Content-Encoding.
+func newEncodingServer(t *testing.T, status int, contentEncoding string, body []byte) (*httptest.Server, *string) {
+	t.Helper()
+
+	var acceptEncoding string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		acceptEncoding = r.Header.Get("Accept-Encoding")
+		if contentEncoding != "" {
+			w.Header().Set("Content-Encoding", contentEncoding)
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &acceptEncoding
+}
+
+func TestClient_ContentEncoding(t *testing.T) {
+	items, err := json.Marshal([]Item{{MarketHashName: "AK-47 | Redline (Field-Tested)", Currency: "EUR"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name            string
+		contentEncoding string
+		body            []byte
+	}{
+		{name: "brotli", contentEncoding: "br", body: encode(t, "br", items)},
+		{name: "gzip", contentEncoding: "gzip", body: encode(t, "gzip", items)},
+		{name: "zlib deflate", contentEncoding: "deflate", body: encode(t, "deflate", items)},
+		{name: "raw deflate", contentEncoding: "deflate", body: encode(t, "raw-deflate", items)},
+		{name: "zstd", contentEncoding: "zstd", body: encode(t, "zstd", items)},
+		{name: "identity", contentEncoding: "identity", body: items},
+		{name: "no content encoding", body: items},
+		{name: "encoding is case insensitive", contentEncoding: "GZIP", body: encode(t, "gzip", items)},
+		{name: "several encodings", contentEncoding: "gzip, br", body: encode(t, "br", encode(t, "gzip", items))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, accept := newEncodingServer(t, http.StatusOK, tt.contentEncoding, tt.body)
+			c := newTestClient(t, server.URL, NoRetry)
+
+			got, err := c.GetItems(context.Background(), DefaultAppID, DefaultCurrency, true)
+			if err != nil {
+				t.Fatalf("got unexpected error: %v", err)
+			}
+			if len(got) != 1 || got[0].MarketHashName != "AK-47 | Redline (Field-Tested)" {
+				t.Errorf("got items %+v", got)
+			}
+			if *accept != acceptEncoding {
+				t.Errorf("got Accept-Encoding %q, want %q", *accept, acceptEncoding)
+			}
+		})
+	}
+
+	t.Run("unsupported encoding", func(t *testing.T) {
+		server, _ := newEncodingServer(t, http.StatusOK, "compress", items)
+		c := newTestClient(t, server.URL, NoRetry)
+
+		if _, err := c.GetItems(context.Background(), DefaultAppID, DefaultCurrency, true); !errors.Is(err, ErrUnsupportedEncoding) {
+			t.Errorf("got error %v, want %v", err, ErrUnsupportedEncoding)
+		}
+	})
+
+	t.Run("compressed error body", func(t *testing.T) {
+		server, _ := newEncodingServer(t, http.StatusBadRequest, "gzip", encode(t, "gzip", []byte(`{"errors":["invalid currency"]}`)))
+		c := newTestClient(t, server.URL, NoRetry)
+
+		_, err := c.GetItems(context.Background(), DefaultAppID, DefaultCurrency, true)
+
+		var statusErr *StatusError
+		if !errors.As(err, &statusErr) || !strings.Contains(statusErr.Body, "invalid currency") {
+			t.Errorf("got error %v, want status error with decoded body", err)
+		}
+	})
+}
+
+func TestClient_MaxResponseSize(t *testing.T) {
+	// Compresses to a few kilobytes and expands to 10 MB of spaces before the JSON.
+	bomb := append(bytes.Repeat([]byte(" "), 10<<20), "[]"...)
+
+	for _, encoding := range []string{"br", "gzip", "zstd"} {
+		t.Run(encoding, func(t *testing.T) {
+			server, _ := newEncodingServer(t, http.StatusOK, encoding, encode(t, encoding, bomb))
+			c, err := NewClient("", "", server.URL, WithMaxResponseSize(1<<20))
+			if err != nil {
+				t.Fatalf("failed to create client: %v", err)
+			}
+
+			if _, err = c.GetItems(context.Background(), DefaultAppID, DefaultCurrency, true); !errors.Is(err, ErrResponseTooLarge) {
+				t.Errorf("got error %v, want %v", err, ErrResponseTooLarge)
+			}
+		})
+	}
+
+	t.Run("body of exactly the limit fits", func(t *testing.T) {
+		body := []byte("[]")
+		server, _ := newEncodingServer(t, http.StatusOK, "gzip", encode(t, "gzip", body))
+		c, err := NewClient("", "", server.URL, WithMaxResponseSize(int64(len(body))))
+		if err != nil {
+			t.Fatalf("failed to create client: %v", err)
+		}
+
+		if _, err = c.GetItems(context.Background(), DefaultAppID, DefaultCurrency, true); err != nil {
+			t.Errorf("got unexpected error: %v", err)
+		}
+	})
+}
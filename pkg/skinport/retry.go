@@ -0,0 +1,94 @@
+package skinport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how transient Skinport failures are retried. Every attempt spends a rate limiter token.
+type RetryPolicy struct {
+	MaxAttempts       int           // total attempts including the first one, 1 disables retries
+	BaseBackoff       time.Duration // delay before the first retry, doubled on every next one
+	MaxBackoff        time.Duration // upper bound of a single delay
+	Jitter            float64       // random +/- fraction of the delay, 0..1
+	RetryableStatuses []int         // response statuses worth retrying
+}
+
+// NoRetry makes a single attempt.
+var NoRetry = RetryPolicy{MaxAttempts: 1}
+
+// DefaultRetryPolicy retries server errors and network failures twice.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:       3,
+	BaseBackoff:       500 * time.Millisecond,
+	MaxBackoff:        5 * time.Second,
+	Jitter:            0.2,
+	RetryableStatuses: []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout},
+}
+
+// StatusError is returned when Skinport answers with an unexpected status.
+type StatusError struct {
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration // parsed Retry-After header of 503 responses
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("skinport API returned status %d: %s", e.StatusCode, e.Body)
+}
+
+// backoff returns the delay before the given retry (1 for the first retry).
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	delay := p.BaseBackoff << (retry - 1)
+	if delay <= 0 || (p.MaxBackoff > 0 && delay > p.MaxBackoff) {
+		delay = p.MaxBackoff
+	}
+
+	if p.Jitter > 0 {
+		delay += time.Duration((rand.Float64()*2 - 1) * p.Jitter * float64(delay))
+	}
+
+	return max(delay, 0)
+}
+
+// retryable tells whether err is a transient failure. The caller's context must be checked before,
+// so a deadline error here can only come from the per-attempt timeout.
+func (p RetryPolicy) retryable(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return slices.Contains(p.RetryableStatuses, statusErr.StatusCode)
+	}
+
+	return unavailable(err)
+}
+
+// unavailable tells whether err means Skinport could not be reached or did not answer in time.
+func unavailable(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// parseRetryAfter reads a Retry-After header given either in seconds or as an http date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0), true
+	}
+
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0), true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,159 @@
+package salefeed
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Engine.IO v4 packet types, sent as the first character of text frames.
+const (
+	engineOpen  = '0'
+	engineClose = '1'
+	enginePing  = '2'
+	enginePong  = '3'
+)
+
+// Socket.IO packet types. With the msgpack parser a packet is a msgpack map sent as a binary frame.
+const (
+	packetConnect      = 0
+	packetDisconnect   = 1
+	packetEvent        = 2
+	packetConnectError = 4
+)
+
+type packet struct {
+	Type int                `msgpack:"type"`
+	Nsp  string             `msgpack:"nsp"`
+	Data msgpack.RawMessage `msgpack:"data,omitempty"`
+}
+
+// event splits the data of an event packet, an array of the event name and its argument.
+func (p packet) event() (string, msgpack.RawMessage, error) {
+	var args []msgpack.RawMessage
+	if err := unmarshal(p.Data, &args); err != nil || len(args) == 0 {
+		return "", nil, fmt.Errorf("invalid event packet: %v", err)
+	}
+
+	var name string
+	if err := unmarshal(args[0], &name); err != nil {
+		return "", nil, fmt.Errorf("invalid event name: %w", err)
+	}
+
+	if len(args) < 2 {
+		return name, nil, nil
+	}
+	return name, args[1], nil
+}
+
+type joinRequest struct {
+	Currency string `msgpack:"currency"`
+	Locale   string `msgpack:"locale"`
+	AppID    int    `msgpack:"appid"`
+}
+
+// handshake is the Engine.IO open packet.
+type handshake struct {
+	SID          string `json:"sid"`
+	PingInterval int    `json:"pingInterval"` // milliseconds
+	PingTimeout  int    `json:"pingTimeout"`  // milliseconds
+}
+
+type session struct {
+	conn *websocket.Conn
+	// readTimeout is how long the server may stay silent: it pings every pingInterval.
+	readTimeout time.Duration
+}
+
+// handshake reads the Engine.IO open packet and connects to the default Socket.IO namespace.
+func (s *session) handshake() error {
+	_ = s.conn.SetReadDeadline(time.Now().Add(handshakeTimeout))
+
+	msgType, data, err := s.conn.ReadMessage()
+	if err != nil {
+		return fmt.Errorf("failed to read handshake: %w", err)
+	}
+	if msgType != websocket.TextMessage || len(data) == 0 || data[0] != engineOpen {
+		return fmt.Errorf("unexpected handshake %q", data)
+	}
+
+	var hs handshake
+	if err = json.Unmarshal(data[1:], &hs); err != nil {
+		return fmt.Errorf("invalid handshake: %w", err)
+	}
+	s.readTimeout = time.Duration(hs.PingInterval+hs.PingTimeout) * time.Millisecond
+	if s.readTimeout <= 0 {
+		s.readTimeout = time.Minute
+	}
+
+	return s.write(packet{Type: packetConnect, Nsp: "/"})
+}
+
+// next returns the next Socket.IO packet, answering Engine.IO pings on the way.
+func (s *session) next() (packet, error) {
+	for {
+		_ = s.conn.SetReadDeadline(time.Now().Add(s.readTimeout))
+
+		msgType, data, err := s.conn.ReadMessage()
+		if err != nil {
+			return packet{}, err
+		}
+
+		if msgType == websocket.TextMessage {
+			if len(data) == 0 {
+				continue
+			}
+			switch data[0] {
+			case enginePing:
+				if err = s.writeFrame(websocket.TextMessage, []byte{enginePong}); err != nil {
+					return packet{}, err
+				}
+			case engineClose:
+				return packet{}, errors.New("skinport closed the connection")
+			}
+			continue
+		}
+
+		var pkt packet
+		if err = unmarshal(data, &pkt); err != nil {
+			return packet{}, fmt.Errorf("invalid packet: %w", err)
+		}
+		if pkt.Nsp != "" && pkt.Nsp != "/" {
+			continue
+		}
+
+		return pkt, nil
+	}
+}
+
+func (s *session) emit(name string, arg any) error {
+	data, err := msgpack.Marshal([]any{name, arg})
+	if err != nil {
+		return err
+	}
+
+	return s.write(packet{Type: packetEvent, Nsp: "/", Data: data})
+}
+
+func (s *session) write(pkt packet) error {
+	data, err := msgpack.Marshal(pkt)
+	if err != nil {
+		return err
+	}
+
+	return s.writeFrame(websocket.BinaryMessage, data)
+}
+
+func (s *session) writeFrame(msgType int, data []byte) error {
+	_ = s.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	return s.conn.WriteMessage(msgType, data)
+}
+
+// unmarshal decodes msgpack, fields Skinport adds later are ignored.
+func unmarshal(data []byte, v any) error {
+	return msgpack.Unmarshal(data, v)
+}
@@ -0,0 +1,304 @@
+// Package salefeed consumes the Skinport live sale feed: new listings and sales as they happen.
+// The feed is a Socket.IO namespace served over Engine.IO v4 websockets with the msgpack parser.
+package salefeed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// DefaultURL is the Skinport Socket.IO endpoint with the websocket transport.
+const DefaultURL = "wss://skinport.com/socket.io/?EIO=4&transport=websocket"
+
+const (
+	defaultMinBackoff = time.Second
+	defaultMaxBackoff = time.Minute
+	backoffJitter     = 0.2
+	handshakeTimeout  = 10 * time.Second
+	writeTimeout      = 10 * time.Second
+)
+
+// EventType tells whether the sales of an event were listed or sold.
+type EventType string
+
+const (
+	EventListed EventType = "listed"
+	EventSold   EventType = "sold"
+)
+
+// Event is one saleFeed message, Skinport batches several sales into it.
+type Event struct {
+	Type  EventType `msgpack:"eventType"`
+	Sales []Sale    `msgpack:"sales"`
+}
+
+// Sale is a listing on Skinport. Prices are in cents of Currency.
+type Sale struct {
+	ID             int64    `msgpack:"id"`
+	SaleID         int64    `msgpack:"saleId"`
+	ProductID      int64    `msgpack:"productId"`
+	AppID          int      `msgpack:"appid"`
+	MarketHashName string   `msgpack:"marketHashName"`
+	URL            string   `msgpack:"url"`
+	SuggestedPrice int64    `msgpack:"suggestedPrice"`
+	SalePrice      int64    `msgpack:"salePrice"`
+	Currency       string   `msgpack:"currency"`
+	SaleStatus     string   `msgpack:"saleStatus"`
+	Lock           *string  `msgpack:"lock"` // end of the trade lock, nil for tradable items
+	Wear           *float64 `msgpack:"wear"`
+}
+
+// Price returns the sale price in currency units.
+func (s Sale) Price() float64 {
+	return float64(s.SalePrice) / 100
+}
+
+// Tradable tells whether the item can be traded right away.
+func (s Sale) Tradable() bool {
+	return s.Lock == nil
+}
+
+type Option func(*Client)
+
+// WithBackoff sets the delay before the first reconnect, doubled up to maxBackoff on every failed one.
+func WithBackoff(minBackoff, maxBackoff time.Duration) Option {
+	return func(c *Client) {
+		c.minBackoff = minBackoff
+		c.maxBackoff = maxBackoff
+	}
+}
+
+// WithLocale sets the language of localized sale fields, "en" by default.
+func WithLocale(locale string) Option {
+	return func(c *Client) {
+		c.locale = locale
+	}
+}
+
+// Client keeps a connection to the sale feed of one app and currency and fans the events out to subscribers.
+type Client struct {
+	url        string
+	appID      int
+	currency   string
+	locale     string
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	dialer     *websocket.Dialer
+
+	mu     sync.Mutex
+	subs   map[*Subscription]struct{}
+	closed bool
+
+	connected   atomic.Bool
+	lastEventAt atomic.Int64
+	dropped     atomic.Int64
+}
+
+func New(url string, appID int, currency string, opts ...Option) *Client {
+	c := &Client{
+		url:        url,
+		appID:      appID,
+		currency:   currency,
+		locale:     "en",
+		minBackoff: defaultMinBackoff,
+		maxBackoff: defaultMaxBackoff,
+		dialer: &websocket.Dialer{
+			Proxy:            http.ProxyFromEnvironment,
+			HandshakeTimeout: handshakeTimeout,
+		},
+		subs: make(map[*Subscription]struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// Connected tells whether the client has joined the feed.
+func (c *Client) Connected() bool {
+	return c.connected.Load()
+}
+
+// LastEventAt returns when the last event was received, zero if none was.
+func (c *Client) LastEventAt() time.Time {
+	if nanos := c.lastEventAt.Load(); nanos != 0 {
+		return time.Unix(0, nanos)
+	}
+	return time.Time{}
+}
+
+// Dropped returns how many events were not delivered to subscribers that lagged behind.
+func (c *Client) Dropped() int64 {
+	return c.dropped.Load()
+}
+
+// Run connects to the feed and reconnects with backoff until ctx is done, then closes the subscriptions.
+func (c *Client) Run(ctx context.Context) {
+	defer c.closeSubscriptions()
+
+	backoff := c.minBackoff
+	for {
+		joined, err := c.session(ctx)
+		c.connected.Store(false)
+		if ctx.Err() != nil {
+			return
+		}
+
+		if joined {
+			backoff = c.minBackoff
+		}
+
+		delay := jitter(backoff)
+		log.Printf("[WARN] skinport sale feed disconnected: %v, reconnecting in %v\n", err, delay)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+
+		backoff = min(backoff*2, c.maxBackoff)
+	}
+}
+
+// session runs one connection until it fails. joined reports whether the feed was joined,
+// so that a connection that worked for a while resets the backoff.
+func (c *Client) session(ctx context.Context) (joined bool, err error) {
+	conn, _, err := c.dialer.DialContext(ctx, c.url, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to connect: %w", err)
+	}
+	defer conn.Close()
+
+	// Unblocks the read below when the context is done.
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	s := &session{conn: conn}
+	if err = s.handshake(); err != nil {
+		return false, err
+	}
+
+	for {
+		pkt, err := s.next()
+		if err != nil {
+			return joined, err
+		}
+
+		switch pkt.Type {
+		case packetConnect:
+			if err = s.emit("saleFeedJoin", joinRequest{Currency: c.currency, Locale: c.locale, AppID: c.appID}); err != nil {
+				return joined, err
+			}
+			joined = true
+			c.connected.Store(true)
+			log.Printf("joined skinport sale feed %d/%s\n", c.appID, c.currency)
+		case packetConnectError:
+			return joined, fmt.Errorf("skinport refused the connection: %s", pkt.Data)
+		case packetDisconnect:
+			return joined, errors.New("skinport closed the namespace")
+		case packetEvent:
+			c.handleEvent(pkt)
+		}
+	}
+}
+
+func (c *Client) handleEvent(pkt packet) {
+	name, payload, err := pkt.event()
+	if err != nil {
+		log.Printf("[WARN] skinport sale feed: %v\n", err)
+		return
+	}
+	if name != "saleFeed" {
+		return
+	}
+
+	var event Event
+	if err = unmarshal(payload, &event); err != nil {
+		log.Printf("[WARN] skinport sale feed: failed to decode sale event: %v\n", err)
+		return
+	}
+
+	c.lastEventAt.Store(time.Now().UnixNano())
+	c.publish(event)
+}
+
+// Subscription receives sale events until it is closed or the client stops.
+type Subscription struct {
+	events chan Event
+	client *Client
+	once   sync.Once
+}
+
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+func (s *Subscription) Close() {
+	s.client.mu.Lock()
+	defer s.client.mu.Unlock()
+
+	s.client.remove(s)
+}
+
+// Subscribe returns a subscription buffering up to buffer events. Events that do not fit
+// into the buffer of a slow subscriber are dropped, the feed is never blocked.
+func (c *Client) Subscribe(buffer int) *Subscription {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	sub := &Subscription{events: make(chan Event, max(buffer, 1)), client: c}
+	if c.closed {
+		sub.once.Do(func() { close(sub.events) })
+		return sub
+	}
+
+	c.subs[sub] = struct{}{}
+	return sub
+}
+
+func (c *Client) publish(event Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for sub := range c.subs {
+		select {
+		case sub.events <- event:
+		default:
+			c.dropped.Add(1)
+		}
+	}
+}
+
+// remove must be called with c.mu held.
+func (c *Client) remove(sub *Subscription) {
+	delete(c.subs, sub)
+	sub.once.Do(func() { close(sub.events) })
+}
+
+func (c *Client) closeSubscriptions() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.closed = true
+	for sub := range c.subs {
+		c.remove(sub)
+	}
+}
+
+func jitter(delay time.Duration) time.Duration {
+	return delay + time.Duration((rand.Float64()*2-1)*backoffJitter*float64(delay))
+}
@@ -0,0 +1,262 @@
+package salefeed
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// fakeFeed speaks just enough Engine.IO v4 and Socket.IO to serve saleFeed events.
+type fakeFeed struct {
+	t        *testing.T
+	server   *httptest.Server
+	sessions atomic.Int32
+	// serve is called after a client joined the feed, the session ends when it returns.
+	serve func(session int, conn *websocket.Conn)
+	join  chan joinRequest
+}
+
+func newFakeFeed(t *testing.T, serve func(session int, conn *websocket.Conn)) *fakeFeed {
+	t.Helper()
+
+	f := &fakeFeed{t: t, serve: serve, join: make(chan joinRequest, 10)}
+	upgrader := websocket.Upgrader{}
+
+	f.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("EIO") != "4" || r.URL.Query().Get("transport") != "websocket" {
+			t.Errorf("unexpected query %q", r.URL.RawQuery)
+		}
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("failed to upgrade: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		session := int(f.sessions.Add(1))
+		_ = conn.WriteMessage(websocket.TextMessage, []byte(`0{"sid":"abc","upgrades":[],"pingInterval":25000,"pingTimeout":20000}`))
+
+		pkt := f.readPacket(conn)
+		if pkt.Type != packetConnect || pkt.Nsp != "/" {
+			t.Errorf("got packet %+v, want connect to /", pkt)
+			return
+		}
+		f.writePacket(conn, packet{Type: packetConnect, Nsp: "/", Data: mustMarshal(t, map[string]string{"sid": "def"})})
+
+		pkt = f.readPacket(conn)
+		name, payload, err := pkt.event()
+		if err != nil || name != "saleFeedJoin" {
+			t.Errorf("got event %q (%v), want saleFeedJoin", name, err)
+			return
+		}
+		var req joinRequest
+		if err = unmarshal(payload, &req); err != nil {
+			t.Errorf("invalid join request: %v", err)
+		}
+		f.join <- req
+
+		f.serve(session, conn)
+	}))
+	t.Cleanup(f.server.Close)
+
+	return f
+}
+
+func (f *fakeFeed) url() string {
+	return "ws" + strings.TrimPrefix(f.server.URL, "http") + "/socket.io/?EIO=4&transport=websocket"
+}
+
+func (f *fakeFeed) readPacket(conn *websocket.Conn) packet {
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		f.t.Errorf("failed to read packet: %v", err)
+		return packet{}
+	}
+
+	var pkt packet
+	if err = unmarshal(data, &pkt); err != nil {
+		f.t.Errorf("invalid packet %q: %v", data, err)
+	}
+	return pkt
+}
+
+func (f *fakeFeed) writePacket(conn *websocket.Conn, pkt packet) {
+	if err := conn.WriteMessage(websocket.BinaryMessage, mustMarshal(f.t, pkt)); err != nil {
+		f.t.Errorf("failed to write packet: %v", err)
+	}
+}
+
+func (f *fakeFeed) sendSales(conn *websocket.Conn, event map[string]any) {
+	f.writePacket(conn, packet{Type: packetEvent, Nsp: "/", Data: mustMarshal(f.t, []any{"saleFeed", event})})
+}
+
+func mustMarshal(t *testing.T, v any) []byte {
+	t.Helper()
+
+	data, err := msgpack.Marshal(v)
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+	return data
+}
+
+func receive(t *testing.T, sub *Subscription) Event {
+	t.Helper()
+
+	select {
+	case event, ok := <-sub.Events():
+		if !ok {
+			t.Fatal("subscription closed")
+		}
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event received")
+		return Event{}
+	}
+}
+
+func TestClient(t *testing.T) {
+	t.Run("joins the feed and decodes sales", func(t *testing.T) {
+		pong := make(chan string, 1)
+		feed := newFakeFeed(t, func(_ int, conn *websocket.Conn) {
+			_ = conn.WriteMessage(websocket.TextMessage, []byte("2"))
+			_, data, _ := conn.ReadMessage()
+			pong <- string(data)
+
+			// Events of other names and unknown fields are ignored.
+			feed := map[string]any{"eventType": "listed", "sales": []map[string]any{{
+				"id": 1, "saleId": 42, "appid": 730, "marketHashName": "AK-47 | Redline (Field-Tested)",
+				"salePrice": 2510, "suggestedPrice": 2700, "currency": "EUR", "lock": nil, "stickers": []string{"x"},
+			}}}
+			_ = conn.WriteMessage(websocket.BinaryMessage, mustMarshal(t, packet{Type: packetEvent, Nsp: "/", Data: mustMarshal(t, []any{"maintenance", nil})}))
+			_ = conn.WriteMessage(websocket.BinaryMessage, mustMarshal(t, packet{Type: packetEvent, Nsp: "/", Data: mustMarshal(t, []any{"saleFeed", feed})}))
+
+			_, _, _ = conn.ReadMessage() // until the client goes away
+		})
+
+		c := New(feed.url(), 730, "EUR")
+		sub := c.Subscribe(10)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			c.Run(ctx)
+			close(done)
+		}()
+
+		if req := <-feed.join; req != (joinRequest{Currency: "EUR", Locale: "en", AppID: 730}) {
+			t.Errorf("got join request %+v", req)
+		}
+		if got := <-pong; got != "3" {
+			t.Errorf("got %q in reply to ping, want pong", got)
+		}
+
+		event := receive(t, sub)
+		if event.Type != EventListed || len(event.Sales) != 1 {
+			t.Fatalf("got event %+v", event)
+		}
+		sale := event.Sales[0]
+		if sale.SaleID != 42 || sale.MarketHashName != "AK-47 | Redline (Field-Tested)" || sale.Price() != 25.10 || !sale.Tradable() {
+			t.Errorf("got sale %+v", sale)
+		}
+		if !c.Connected() || c.LastEventAt().IsZero() {
+			t.Error("client must be connected and have received an event")
+		}
+
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("Run did not return after cancel")
+		}
+		if _, ok := <-sub.Events(); ok {
+			t.Error("subscription must be closed when the client stops")
+		}
+	})
+
+	t.Run("reconnects with backoff", func(t *testing.T) {
+		feed := newFakeFeed(t, func(session int, conn *websocket.Conn) {
+			if session == 1 {
+				return // drops the connection
+			}
+			feed := map[string]any{"eventType": "sold", "sales": []map[string]any{{"saleId": session}}}
+			_ = conn.WriteMessage(websocket.BinaryMessage, mustMarshal(t, packet{Type: packetEvent, Nsp: "/", Data: mustMarshal(t, []any{"saleFeed", feed})}))
+			_, _, _ = conn.ReadMessage()
+		})
+
+		c := New(feed.url(), 730, "EUR", WithBackoff(10*time.Millisecond, 50*time.Millisecond))
+		sub := c.Subscribe(10)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go c.Run(ctx)
+
+		event := receive(t, sub)
+		if event.Type != EventSold || event.Sales[0].SaleID != 2 {
+			t.Errorf("got event %+v, want the sale of the second session", event)
+		}
+	})
+
+	t.Run("connect error", func(t *testing.T) {
+		var attempts atomic.Int32
+		upgrader := websocket.Upgrader{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts.Add(1)
+			conn, err := upgrader.Upgrade(w, r, nil)
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+
+			_ = conn.WriteMessage(websocket.TextMessage, []byte(`0{"sid":"abc","pingInterval":25000,"pingTimeout":20000}`))
+			_, _, _ = conn.ReadMessage()
+			_ = conn.WriteMessage(websocket.BinaryMessage, mustMarshal(t, packet{Type: packetConnectError, Nsp: "/", Data: mustMarshal(t, map[string]string{"message": "forbidden"})}))
+			_, _, _ = conn.ReadMessage()
+		}))
+		defer server.Close()
+
+		c := New("ws"+strings.TrimPrefix(server.URL, "http"), 730, "EUR", WithBackoff(10*time.Millisecond, 10*time.Millisecond))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+		defer cancel()
+		c.Run(ctx)
+
+		if attempts.Load() < 2 {
+			t.Errorf("got %d connection attempts, want reconnects after the connect error", attempts.Load())
+		}
+		if c.Connected() {
+			t.Error("client must not be connected")
+		}
+	})
+}
+
+func TestClient_SlowSubscriber(t *testing.T) {
+	c := New("", 730, "EUR")
+	slow := c.Subscribe(1)
+	fast := c.Subscribe(10)
+
+	for range 3 {
+		c.publish(Event{Type: EventListed})
+	}
+
+	if len(slow.Events()) != 1 || len(fast.Events()) != 3 {
+		t.Errorf("got %d and %d buffered events, want 1 and 3", len(slow.Events()), len(fast.Events()))
+	}
+	if c.Dropped() != 2 {
+		t.Errorf("got %d dropped events, want 2", c.Dropped())
+	}
+
+	slow.Close()
+	slow.Close()
+	if _, ok := <-slow.Events(); !ok {
+		t.Error("buffered event must still be readable after close")
+	}
+}
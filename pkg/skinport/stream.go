@@ -0,0 +1,66 @@
+package skinport
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// DecodeItems reads a JSON array of items token by token and calls fn for each of them,
+// so that only one item is held in memory at a time. A null body is an empty catalogue.
+// A body cut before the end of the array fails with io.ErrUnexpectedEOF.
+func DecodeItems(r io.Reader, fn func(Item) error) error {
+	er := &eofReader{r: r}
+	dec := json.NewDecoder(er)
+
+	tok, err := dec.Token()
+	if err != nil {
+		return er.truncated(err)
+	}
+	if tok == nil {
+		return nil
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("expected an array of items, got %v", tok)
+	}
+
+	for dec.More() {
+		var item Item
+		if err = dec.Decode(&item); err != nil {
+			return er.truncated(err)
+		}
+		if err = fn(item); err != nil {
+			return err
+		}
+	}
+
+	if _, err = dec.Token(); err != nil {
+		return er.truncated(err)
+	}
+	return nil
+}
+
+// eofReader remembers whether the underlying reader was read to the end.
+type eofReader struct {
+	r   io.Reader
+	eof bool
+}
+
+func (r *eofReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if errors.Is(err, io.EOF) {
+		r.eof = true
+	}
+	return n, err
+}
+
+// truncated turns the errors json reports for a body that ended too early into io.ErrUnexpectedEOF.
+// Between tokens json does not return io.ErrUnexpectedEOF but a syntax error with a fixed message.
+func (r *eofReader) truncated(err error) error {
+	var syntaxErr *json.SyntaxError
+	if r.eof && (errors.Is(err, io.EOF) || errors.As(err, &syntaxErr) && syntaxErr.Error() == "unexpected end of JSON input") {
+		return fmt.Errorf("%w: %v", io.ErrUnexpectedEOF, err)
+	}
+	return err
+}
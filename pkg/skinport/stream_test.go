@@ -0,0 +1,86 @@
+package skinport
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestDecodeItems(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		wantNames []string
+		wantErr   error
+	}{
+		{
+			name:      "array",
+			body:      `[{"market_hash_name":"AK-47 | Redline (Field-Tested)"},{"market_hash_name":"AWP | Asiimov (Field-Tested)"}]`,
+			wantNames: []string{"AK-47 | Redline (Field-Tested)", "AWP | Asiimov (Field-Tested)"},
+		},
+		{name: "empty array", body: `[]`},
+		{name: "null", body: `null`},
+		{
+			name:      "cut between items",
+			body:      `[{"market_hash_name":"AK-47 | Redline (Field-Tested)"},`,
+			wantNames: []string{"AK-47 | Redline (Field-Tested)"},
+			wantErr:   io.ErrUnexpectedEOF,
+		},
+		{
+			name:      "truncated array",
+			body:      `[{"market_hash_name":"AK-47 | Redline (Field-Tested)"},{"market_hash_name":"AWP`,
+			wantNames: []string{"AK-47 | Redline (Field-Tested)"},
+			wantErr:   io.ErrUnexpectedEOF,
+		},
+		{
+			name:      "missing closing bracket",
+			body:      `[{"market_hash_name":"AK-47 | Redline (Field-Tested)"}`,
+			wantNames: []string{"AK-47 | Redline (Field-Tested)"},
+			wantErr:   io.ErrUnexpectedEOF,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var names []string
+			err := DecodeItems(strings.NewReader(tt.body), func(item Item) error {
+				names = append(names, item.MarketHashName)
+				return nil
+			})
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("got error %v, want %v", err, tt.wantErr)
+			}
+			if strings.Join(names, ";") != strings.Join(tt.wantNames, ";") {
+				t.Errorf("got items %v, want %v", names, tt.wantNames)
+			}
+		})
+	}
+
+	t.Run("invalid json is not reported as truncated", func(t *testing.T) {
+		err := DecodeItems(strings.NewReader(`[{}}`), func(Item) error { return nil })
+		if err == nil || errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("got error %v, want a syntax error", err)
+		}
+	})
+
+	t.Run("object is an error", func(t *testing.T) {
+		if err := DecodeItems(strings.NewReader(`{"errors":[]}`), func(Item) error { return nil }); err == nil {
+			t.Error("expected an error")
+		}
+	})
+
+	t.Run("callback error stops decoding", func(t *testing.T) {
+		errStop := errors.New("stop")
+		calls := 0
+		err := DecodeItems(strings.NewReader(`[{},{},{}]`), func(Item) error {
+			calls++
+			return errStop
+		})
+
+		if !errors.Is(err, errStop) || calls != 1 {
+			t.Errorf("got error %v after %d calls, want %v after 1 call", err, calls, errStop)
+		}
+	})
+}
@@ -0,0 +1,87 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"syscall"
+)
+
+// ErrNonPublicAddress is returned for webhook urls that point into a private, loopback or otherwise internal network.
+var ErrNonPublicAddress = errors.New("webhook address is not public")
+
+// nonPublicPrefixes are special purpose ranges not covered by the netip.Addr predicates.
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),      // "this network"
+	netip.MustParsePrefix("100.64.0.0/10"),  // carrier-grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),   // IETF protocol assignments
+	netip.MustParsePrefix("198.18.0.0/15"),  // benchmarking
+	netip.MustParsePrefix("240.0.0.0/4"),    // reserved, includes the broadcast address
+	netip.MustParsePrefix("64:ff9b::/96"),   // NAT64, may translate to any IPv4 address
+	netip.MustParsePrefix("64:ff9b:1::/48"), // local-use NAT64
+	netip.MustParsePrefix("2001:db8::/32"),  // documentation
+	netip.MustParsePrefix("2002::/16"),      // 6to4, embeds an IPv4 address
+}
+
+// IsPublic reports whether ip is a globally routable unicast address webhooks may be sent to.
+// Loopback, RFC 1918 and unique local, link-local (including 169.254.169.254 metadata endpoints),
+// multicast and unspecified addresses are not public.
+func IsPublic(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return false
+	}
+
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(ip) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// CheckURL resolves the host of rawURL and fails with ErrNonPublicAddress unless every address it resolves to is public.
+// The client checks the dialed address again, as DNS may change between the two.
+func CheckURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+
+	host := u.Hostname()
+	if ip, err := netip.ParseAddr(host); err == nil {
+		if !IsPublic(ip) {
+			return fmt.Errorf("%s: %w", host, ErrNonPublicAddress)
+		}
+		return nil
+	}
+
+	ips, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", host, err)
+	}
+	for _, ip := range ips {
+		if !IsPublic(ip) {
+			return fmt.Errorf("%s resolves to %s: %w", host, ip, ErrNonPublicAddress)
+		}
+	}
+
+	return nil
+}
+
+// refuseNonPublic is a net.Dialer Control hook run after DNS resolution, so it sees the address actually dialed.
+func refuseNonPublic(_, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if !IsPublic(addrPort.Addr()) {
+		return fmt.Errorf("%s: %w", addrPort.Addr(), ErrNonPublicAddress)
+	}
+
+	return nil
+}
@@ -0,0 +1,147 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// SignatureHeader carries "sha256=" and the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with the secret.
+	SignatureHeader = "X-Webhook-Signature"
+	// TimestampHeader carries the unix time the request was signed at, so receivers can reject replays.
+	TimestampHeader = "X-Webhook-Timestamp"
+	// EventIDHeader is the same for every attempt of one event, so receivers can drop duplicates.
+	EventIDHeader = "X-Webhook-Event-ID"
+)
+
+// Result describes the last attempt of a delivery.
+type Result struct {
+	Attempts   int
+	StatusCode int // 0 when no response was received
+	Err        error
+}
+
+// Client posts signed JSON webhooks, retrying network errors, 429 and 5xx responses.
+type Client struct {
+	client       *http.Client
+	maxAttempts  int
+	baseBackoff  time.Duration
+	allowPrivate bool
+}
+
+type Option func(*Client)
+
+// WithPrivateNetworks lets the client send webhooks to non-public addresses, e.g. to local test servers.
+func WithPrivateNetworks() Option {
+	return func(c *Client) {
+		c.allowPrivate = true
+	}
+}
+
+func NewClient(timeout time.Duration, maxAttempts int, baseBackoff time.Duration, opts ...Option) *Client {
+	c := &Client{
+		maxAttempts: max(maxAttempts, 1),
+		baseBackoff: baseBackoff,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	dialer := &net.Dialer{Timeout: timeout}
+	if !c.allowPrivate {
+		// Alert owners choose the url, so the server must not be usable to reach its own network.
+		dialer.Control = refuseNonPublic
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil // a proxy would dial the webhook host instead of us, bypassing the check
+	transport.DialContext = dialer.DialContext
+
+	c.client = &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		// Redirects are not followed: the signature is for the registered url only.
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+
+	return c
+}
+
+// Sign returns the signature header value of body.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a signature made by Sign in constant time.
+func Verify(secret string, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// Send posts payload to url. The delivery succeeds on any 2xx response.
+func (c *Client) Send(ctx context.Context, url, secret, eventID string, payload any) Result {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return Result{Err: fmt.Errorf("failed to marshal webhook payload: %w", err)}
+	}
+
+	var result Result
+	for attempt := 1; ; attempt++ {
+		result.Attempts = attempt
+
+		var retry bool
+		result.StatusCode, retry, result.Err = c.post(ctx, url, secret, eventID, body)
+		if result.Err == nil || !retry || attempt >= c.maxAttempts {
+			return result
+		}
+
+		timer := time.NewTimer(c.baseBackoff << (attempt - 1))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return result
+		}
+	}
+}
+
+func (c *Client) post(ctx context.Context, url, secret, eventID string, body []byte) (int, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, false, err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventIDHeader, eventID)
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, Sign(secret, timestamp, body))
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, ctx.Err() == nil && !errors.Is(err, ErrNonPublicAddress), err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<10))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp.StatusCode, false, nil
+	}
+
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+	return resp.StatusCode, retry, fmt.Errorf("webhook returned status %d", resp.StatusCode)
+}
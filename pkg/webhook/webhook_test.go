@@ -0,0 +1,169 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_Send(t *testing.T) {
+	const secret = "s3cr3t"
+	payload := map[string]any{"alert_id": 1, "price": 19.5}
+
+	t.Run("signed request", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			timestamp, err := strconv.ParseInt(r.Header.Get(TimestampHeader), 10, 64)
+			if err != nil {
+				t.Errorf("invalid timestamp header: %v", err)
+			}
+
+			if !Verify(secret, timestamp, body, r.Header.Get(SignatureHeader)) {
+				t.Error("signature does not match the body")
+			}
+			if Verify("other", timestamp, body, r.Header.Get(SignatureHeader)) {
+				t.Error("signature must depend on the secret")
+			}
+			if r.Header.Get(EventIDHeader) != "event-1" || r.Header.Get("Content-Type") != "application/json" {
+				t.Errorf("unexpected headers %v", r.Header)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		result := NewClient(time.Second, 3, time.Millisecond, WithPrivateNetworks()).Send(context.Background(), server.URL, secret, "event-1", payload)
+		if result.Err != nil || result.StatusCode != http.StatusNoContent || result.Attempts != 1 {
+			t.Errorf("got result %+v, want one successful attempt", result)
+		}
+	})
+
+	t.Run("retries server errors", func(t *testing.T) {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) < 3 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		result := NewClient(time.Second, 3, time.Millisecond, WithPrivateNetworks()).Send(context.Background(), server.URL, secret, "event-1", payload)
+		if result.Err != nil || result.Attempts != 3 {
+			t.Errorf("got result %+v, want success on the third attempt", result)
+		}
+	})
+
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		result := NewClient(time.Second, 2, time.Millisecond, WithPrivateNetworks()).Send(context.Background(), server.URL, secret, "event-1", payload)
+		if result.Err == nil || result.StatusCode != http.StatusServiceUnavailable || result.Attempts != 2 || calls.Load() != 2 {
+			t.Errorf("got result %+v after %d calls, want failure after 2 attempts", result, calls.Load())
+		}
+	})
+
+	t.Run("does not retry client errors", func(t *testing.T) {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusGone)
+		}))
+		defer server.Close()
+
+		result := NewClient(time.Second, 3, time.Millisecond, WithPrivateNetworks()).Send(context.Background(), server.URL, secret, "event-1", payload)
+		if result.Err == nil || result.Attempts != 1 || calls.Load() != 1 {
+			t.Errorf("got result %+v after %d calls, want a single failed attempt", result, calls.Load())
+		}
+	})
+
+	t.Run("does not follow redirects", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, "http://example.com/", http.StatusFound)
+		}))
+		defer server.Close()
+
+		result := NewClient(time.Second, 3, time.Millisecond, WithPrivateNetworks()).Send(context.Background(), server.URL, secret, "event-1", payload)
+		if result.Err == nil || result.StatusCode != http.StatusFound {
+			t.Errorf("got result %+v, want the redirect to fail the delivery", result)
+		}
+	})
+
+	t.Run("refuses private addresses", func(t *testing.T) {
+		var calls atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+		}))
+		defer server.Close()
+
+		result := NewClient(time.Second, 3, time.Millisecond).Send(context.Background(), server.URL, secret, "event-1", payload)
+		if !errors.Is(result.Err, ErrNonPublicAddress) || result.Attempts != 1 {
+			t.Errorf("got result %+v, want one refused attempt", result)
+		}
+		if calls.Load() != 0 {
+			t.Errorf("server got %d requests, want none", calls.Load())
+		}
+	})
+}
+
+func TestIsPublic(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{ip: "93.184.216.34", want: true},
+		{ip: "2606:2800:220:1:248:1893:25c8:1946", want: true},
+		{ip: "127.0.0.1", want: false},
+		{ip: "::1", want: false},
+		{ip: "10.1.2.3", want: false},
+		{ip: "172.16.0.1", want: false},
+		{ip: "192.168.1.1", want: false},
+		{ip: "169.254.169.254", want: false},
+		{ip: "::ffff:169.254.169.254", want: false},
+		{ip: "fe80::1", want: false},
+		{ip: "fd00::1", want: false},
+		{ip: "100.64.0.1", want: false},
+		{ip: "0.0.0.0", want: false},
+		{ip: "255.255.255.255", want: false},
+		{ip: "224.0.0.1", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := IsPublic(netip.MustParseAddr(tt.ip)); got != tt.want {
+			t.Errorf("IsPublic(%s) = %t, want %t", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		wantErr bool
+	}{
+		{url: "https://93.184.216.34/hooks"},
+		{url: "http://127.0.0.1:8080/hooks", wantErr: true},
+		{url: "http://[::1]/hooks", wantErr: true},
+		{url: "http://169.254.169.254/latest/meta-data", wantErr: true},
+		{url: "https://10.0.0.5/hooks", wantErr: true},
+		{url: "http://localhost:8080/hooks", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		err := CheckURL(context.Background(), tt.url)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("CheckURL(%s) got error %v, want error %t", tt.url, err, tt.wantErr)
+		}
+	}
+}